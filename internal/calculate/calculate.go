@@ -1,11 +1,153 @@
 package calculate
 
 import (
+	"errors"
+	"fantalegheGO/internal/parser"
+	"fantalegheGO/internal/rules"
+	"fantalegheGO/internal/scoring"
+	"fantalegheGO/internal/teams"
+	"fmt"
+	"io"
 	"mime/multipart"
+	"strings"
 
 	api "github.com/antpas14/fantalegheEV-api"
 )
 
+// ErrInconsistentCalendar is returned when a calendar read without errors
+// fails validation, e.g. a round played twice or a team missing a fixture.
+var ErrInconsistentCalendar = errors.New("inconsistent calendar")
+
+// Options tunes a single calculation request.
+type Options struct {
+	// Mode selects whether invalid calendar data fails the request or is
+	// skipped with a warning.
+	Mode parser.Mode
+	// Sheet is the name or 1-based position of the workbook sheet holding the
+	// calendar. When empty the calendar sheet is found by its content.
+	Sheet string
+	// RulesMode tells whether Rules replace or check the results written in
+	// the calendar. The zero value trusts them.
+	RulesMode rules.Mode
+	Rules     rules.Rules
+	// EVMode selects the goals the teams are compared on for the expected
+	// value.
+	EVMode EVMode
+	// Aliases fold the names a team went by into one, nil when the league
+	// has none. Names differing only in spacing or case are folded anyway.
+	Aliases *teams.Aliases
+	// Scoring are the league points of a win, a draw and a loss, both for the
+	// points and the expected value. The zero value scores 3/1/0.
+	Scoring scoring.ScoringRules
+	// From and To limit the ranking to the league matchdays between them,
+	// both included, e.g. the first half of the season or the state as of a
+	// past round. 0 leaves that end open.
+	From int
+	To   int
+	// Incomplete tells what to do with rounds some fixtures of which are
+	// still to be played. The zero value ranks them as they stand.
+	Incomplete IncompleteMode
+	// Luck, Matchdays and Trend ask for the reports of the same name on the
+	// result, which are left out otherwise.
+	Luck      bool
+	Matchdays bool
+	Trend     bool
+}
+
+// IncompleteMode is the policy for rounds played in part only, as when a
+// Serie A match is postponed.
+type IncompleteMode int
+
+const (
+	// IncompleteInclude ranks the played fixtures of the round as they stand.
+	IncompleteInclude IncompleteMode = iota
+	// IncompleteExclude leaves the round out until it is complete, so the
+	// ranking does not move when the recovery match is played.
+	IncompleteExclude
+	// IncompleteProvisional ranks the played fixtures of the round and marks
+	// the ranks of its teams as provisional.
+	IncompleteProvisional
+)
+
+// ParseIncompleteMode converts a user supplied incomplete rounds policy name,
+// defaulting to include.
+func ParseIncompleteMode(name string) (IncompleteMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "include":
+		return IncompleteInclude, nil
+	case "exclude":
+		return IncompleteExclude, nil
+	case "provisional":
+		return IncompleteProvisional, nil
+	}
+	return IncompleteInclude, fmt.Errorf("calculate: unknown incomplete rounds mode %q", name)
+}
+
+// EVMode selects the goals the all-play-all comparison of the expected value
+// is made on.
+type EVMode int
+
+const (
+	// EVGoals compares the teams on the goals of their results.
+	EVGoals EVMode = iota
+	// EVNeutral compares the teams on the goals the rules give to their
+	// fantasy totals less the home bonus, so that playing at home brings no
+	// advantage against the teams that were not the opponent.
+	EVNeutral
+)
+
+// ParseEVMode converts a user supplied expected value mode name, defaulting
+// to goals.
+func ParseEVMode(name string) (EVMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "goals":
+		return EVGoals, nil
+	case "neutral":
+		return EVNeutral, nil
+	}
+	return EVGoals, fmt.Errorf("calculate: unknown expected value mode %q", name)
+}
+
+// Rank is the standing of a team, with the matches it played, the rounds it
+// rested and its fixtures still to be played in the ranked rounds.
+// Provisional is set when the rank counts a round that is not complete yet.
+type Rank struct {
+	api.Rank
+	Played      int  `json:"played"`
+	Byes        int  `json:"byes"`
+	Pending     int  `json:"pending"`
+	Provisional bool `json:"provisional"`
+}
+
+// APIRanks returns the ranks as the fantalegheEV-api defines them, without
+// the fields this service adds.
+func APIRanks(ranks []Rank) []api.Rank {
+	converted := make([]api.Rank, len(ranks))
+	for i, r := range ranks {
+		converted[i] = r.Rank
+	}
+	return converted
+}
+
+type Result struct {
+	Ranks []Rank
+	// Matchdays break the expected value down round by round. They are nil
+	// unless Options.Matchdays asks for them.
+	Matchdays []Matchday
+	// Trend follows the standings after every round. It is nil unless
+	// Options.Trend asks for it.
+	Trend *Trend
+	// Luck compares the actual points of the teams with their expected ones.
+	// It is nil unless Options.Luck asks for it.
+	Luck *LuckReport
+	// Sheet is the name of the sheet the calendar was read from.
+	Sheet  string
+	Report *parser.ParseReport
+}
+
 type Calculate interface {
-	GetRanks(fileHeader *multipart.FileHeader) ([]api.Rank, error)
+	GetRanks(fileHeader *multipart.FileHeader, opts Options) (*Result, error)
+	// GetRanksFromCalendar ranks a calendar sent as JSON, see
+	// parser.CalendarSchema.
+	GetRanksFromCalendar(calendar io.Reader, opts Options) (*Result, error)
 }
@@ -3,7 +3,11 @@ package calculate
 import (
 	"fantalegheGO/internal/excel"
 	"fantalegheGO/internal/parser"
+	"fantalegheGO/internal/rules"
+	"fantalegheGO/internal/scoring"
+	"fantalegheGO/internal/teams"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"sort"
 
@@ -12,90 +16,415 @@ import (
 
 type CalculateImpl struct {
 	excelService excel.ExcelService // Changed to interface
-	parser       parser.Parser      // Changed to interface
+	readers      map[excel.Format]excel.ExcelService
+	parser       parser.Parser // Changed to interface
+	htmlParser   parser.HTMLParser
 }
 
 type evRankData struct {
-	EvSum       float64
-	TotalPoints int
-	MatchCount  int
+	EvSum        float64
+	TotalPoints  int
+	MatchCount   int
+	ByeCount     int
+	PendingCount int
 }
 
-// NewCalculateImpl now takes interfaces
+// NewCalculateImpl now takes interfaces. es reads Excel workbooks and any
+// upload whose format has no reader of its own.
 func NewCalculateImpl(es excel.ExcelService, p parser.Parser) *CalculateImpl {
 	return &CalculateImpl{
 		excelService: es,
+		readers:      map[excel.Format]excel.ExcelService{excel.FormatXLSX: es},
 		parser:       p,
 	}
 }
 
-func (c *CalculateImpl) GetRanks(fileHeader *multipart.FileHeader) ([]api.Rank, error) {
-	excelRawData, err := c.excelService.ReadExcel(fileHeader)
+// WithReader registers the service reading uploads of the given format.
+func (c *CalculateImpl) WithReader(format excel.Format, reader excel.ExcelService) *CalculateImpl {
+	c.readers[format] = reader
+	return c
+}
+
+// WithHTMLParser registers the parser reading saved calendar pages.
+func (c *CalculateImpl) WithHTMLParser(htmlParser parser.HTMLParser) *CalculateImpl {
+	c.htmlParser = htmlParser
+	return c
+}
+
+// readerFor picks the reader of an upload format.
+func (c *CalculateImpl) readerFor(format excel.Format) excel.ExcelService {
+	if reader, ok := c.readers[format]; ok {
+		return reader
+	}
+	return c.excelService
+}
+
+func (c *CalculateImpl) GetRanks(fileHeader *multipart.FileHeader, opts Options) (*Result, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("failed to read excel file: no file uploaded")
+	}
+	// The format is told by the upload signature, or by its file name and
+	// content type when the signature tells nothing.
+	format := excel.DetectUploadFormat(fileHeader)
+	if format == excel.FormatHTML && c.htmlParser != nil {
+		return c.getRanksFromHTML(fileHeader, opts)
+	}
+
+	// Grid mode keeps every value at its column, so diagnostics name real cells.
+	grid, err := c.readerFor(format).ReadGrid(fileHeader, excel.Options{Sheet: opts.Sheet})
 	if err != nil {
 		// Wrap the error to provide more context.
 		return nil, fmt.Errorf("failed to read excel file: %w", err)
 	}
 
-	results, err := c.parser.GetTeamResults(excelRawData)
+	results, report, err := c.parser.Parse(grid.Values(), opts.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team results: %w", err)
+	}
+
+	result, err := newResult(results, report, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInconsistentCalendar, err)
+	}
+	result.Sheet = grid.Sheet
+	return result, nil
+}
+
+// getRanksFromHTML ranks a saved calendar page, which has no sheets.
+func (c *CalculateImpl) getRanksFromHTML(fileHeader *multipart.FileHeader, opts Options) (*Result, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	results, report, err := c.htmlParser.ParseHTML(file, opts.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team results: %w", err)
+	}
+
+	result, err := newResult(results, report, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInconsistentCalendar, err)
+	}
+	return result, nil
+}
+
+// GetRanksFromCalendar ranks a JSON calendar, which needs no sheet reader.
+func (c *CalculateImpl) GetRanksFromCalendar(calendar io.Reader, opts Options) (*Result, error) {
+	results, report, err := parser.ParseJSON(calendar)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get team results: %w", err)
 	}
 
-	finalRanks := calculate(results)
-	return finalRanks, nil
+	result, err := newResult(results, report, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInconsistentCalendar, err)
+	}
+	return result, nil
+}
+
+// newResult validates the calendar under the canonical team names, scores
+// the results with the rules the request asks for and ranks the teams, adding
+// the reports opts asks for.
+// Findings are added to the report; in strict mode an inconsistent calendar
+// fails with a *parser.ParseError.
+func newResult(results []parser.MatchResults, report *parser.ParseReport, opts Options) (*Result, error) {
+	if report == nil {
+		report = &parser.ParseReport{}
+	}
+	results, points, err := prepare(results, report, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := calculateWith(results, points)
+	if opts.Incomplete == IncompleteProvisional {
+		markProvisional(ranks, results)
+	}
+	result := &Result{Ranks: ranks, Report: report}
+	if opts.Luck {
+		result.Luck = luck(ranks, results, points)
+	}
+	if opts.Matchdays {
+		result.Matchdays = matchdays(results, points)
+	}
+	if opts.Trend {
+		result.Trend = trend(ranks, results, points)
+	}
+	return result, nil
+}
+
+// prepare returns the results as they are ranked, with the points function
+// the teams are compared with.
+func prepare(results []parser.MatchResults, report *parser.ParseReport, opts Options) ([]parser.MatchResults, pointsFunc, error) {
+	if opts.From < 0 || opts.To < 0 {
+		return nil, nil, fmt.Errorf("calculate: matchdays cannot be negative")
+	}
+	if opts.To > 0 && opts.From > opts.To {
+		return nil, nil, fmt.Errorf("calculate: from matchday %d is after to matchday %d", opts.From, opts.To)
+	}
+
+	results = foldTeams(results, opts.Aliases)
+	report.AddValidation(parser.Validate(results), opts.Mode)
+	if report.HasErrors() {
+		return nil, nil, &parser.ParseError{Report: report}
+	}
+
+	// The calendar is validated as a whole, so that a round repeating one
+	// outside the range is still found.
+	results = playedRounds(matchdayRange(results, opts.From, opts.To))
+	results, warnings := opts.Rules.Apply(results, opts.RulesMode)
+	report.Warnings = append(report.Warnings, warnings...)
+	if opts.Incomplete == IncompleteExclude {
+		results = completeRounds(results)
+	}
+	scoringRules := opts.Scoring
+	if scoringRules.IsZero() {
+		scoringRules = scoring.Default()
+	}
+	results = score(results, scoringRules)
+
+	if opts.EVMode == EVNeutral {
+		return results, neutralPoints(opts.Rules, scoringRules), nil
+	}
+	return results, scoredPoints(scoringRules), nil
 }
 
-func calculate(results []parser.MatchResults) []api.Rank {
+// score returns the results with the points of every fixture given by the
+// scoring rules, leaving the parsed results untouched. It is the one place
+// points are assigned, so fixtures whose goals the rules replaced are scored
+// too.
+func score(results []parser.MatchResults, s scoring.ScoringRules) []parser.MatchResults {
+	scored := make([]parser.MatchResults, len(results))
+	for i, matchResults := range results {
+		scored[i] = matchResults
+		if len(matchResults.Fixtures) == 0 {
+			continue
+		}
+		scored[i].Fixtures = append([]parser.Fixture(nil), matchResults.Fixtures...)
+		scored[i].TeamResults = make([]parser.TeamResult, 0, 2*len(matchResults.Fixtures))
+		for j := range scored[i].Fixtures {
+			home, away := &scored[i].Fixtures[j].Home, &scored[i].Fixtures[j].Away
+			home.Points, away.Points = s.Points(home.Goals, away.Goals), s.Points(away.Goals, home.Goals)
+			scored[i].TeamResults = append(scored[i].TeamResults, *home, *away)
+		}
+	}
+	return scored
+}
+
+// matchdayRange returns the rounds from matchday from to matchday to, both
+// included; 0 leaves that end open.
+func matchdayRange(results []parser.MatchResults, from, to int) []parser.MatchResults {
+	if from == 0 && to == 0 {
+		return results
+	}
+	var selected []parser.MatchResults
+	for _, matchResults := range results {
+		if matchResults.Matchday >= from && (to == 0 || matchResults.Matchday <= to) {
+			selected = append(selected, matchResults)
+		}
+	}
+	return selected
+}
+
+// playedRounds returns the rounds at least one fixture of which was played.
+// Rounds not played yet, as the rest of the season in a mid-season export,
+// neither count pending fixtures and byes nor show up in the reports.
+func playedRounds(results []parser.MatchResults) []parser.MatchResults {
+	var played []parser.MatchResults
+	for _, matchResults := range results {
+		if len(matchResults.TeamResults) > 0 {
+			played = append(played, matchResults)
+		}
+	}
+	return played
+}
+
+// completeRounds returns the rounds with no fixture left to play.
+func completeRounds(results []parser.MatchResults) []parser.MatchResults {
+	var complete []parser.MatchResults
+	for _, matchResults := range results {
+		if !matchResults.Incomplete() {
+			complete = append(complete, matchResults)
+		}
+	}
+	return complete
+}
+
+// markProvisional marks the ranks of the teams of incomplete rounds: their
+// expected value moves once the postponed fixtures are played, as the round
+// then has more teams to be compared against.
+func markProvisional(ranks []Rank, results []parser.MatchResults) {
+	provisional := make(map[string]bool)
+	for _, matchResults := range results {
+		if !matchResults.Incomplete() {
+			continue
+		}
+		for _, teamResult := range matchResults.TeamResults {
+			provisional[teamResult.Team] = true
+		}
+		for _, fixture := range matchResults.Postponed {
+			provisional[fixture.Home.Team], provisional[fixture.Away.Team] = true, true
+		}
+	}
+	for i := range ranks {
+		ranks[i].Provisional = provisional[*ranks[i].Team]
+	}
+}
+
+// foldTeams returns the results with every team under its canonical name,
+// leaving the parsed results untouched.
+func foldTeams(results []parser.MatchResults, aliases *teams.Aliases) []parser.MatchResults {
+	names := teams.NewNames(aliases)
+	folded := make([]parser.MatchResults, len(results))
+	for i, matchResults := range results {
+		folded[i] = matchResults
+		folded[i].Fixtures = append([]parser.Fixture(nil), matchResults.Fixtures...)
+		for j := range folded[i].Fixtures {
+			folded[i].Fixtures[j].Home.Team = names.Canonical(folded[i].Fixtures[j].Home.Team)
+			folded[i].Fixtures[j].Away.Team = names.Canonical(folded[i].Fixtures[j].Away.Team)
+		}
+		folded[i].TeamResults = append([]parser.TeamResult(nil), matchResults.TeamResults...)
+		for j := range folded[i].TeamResults {
+			folded[i].TeamResults[j].Team = names.Canonical(folded[i].TeamResults[j].Team)
+		}
+		folded[i].Postponed = append([]parser.Fixture(nil), matchResults.Postponed...)
+		for j := range folded[i].Postponed {
+			folded[i].Postponed[j].Home.Team = names.Canonical(folded[i].Postponed[j].Home.Team)
+			folded[i].Postponed[j].Away.Team = names.Canonical(folded[i].Postponed[j].Away.Team)
+		}
+		folded[i].Byes = append([]string(nil), matchResults.Byes...)
+		for j := range folded[i].Byes {
+			folded[i].Byes[j] = names.Canonical(folded[i].Byes[j])
+		}
+	}
+	return folded
+}
+
+// pointsFunc returns the points t1 would get in a match against t2.
+type pointsFunc func(t1 parser.TeamResult, t2 parser.TeamResult) float64
+
+func calculate(results []parser.MatchResults) []Rank {
+	return calculateWith(results, calculatePoints)
+}
+
+// calculateWith ranks the teams on the average points they would have taken
+// against every other team playing the round. Resting teams and teams of
+// postponed fixtures are left out of their round, only their byes and pending
+// fixtures are counted.
+func calculateWith(results []parser.MatchResults, points pointsFunc) []Rank {
 	evRankMap := make(map[string]evRankData)
 
 	for _, matchResult := range results {
-		for i := 0; i < len(matchResult.TeamResults); i++ {
-			t1 := matchResult.TeamResults[i]
-			currentMatchDayPoints := float64(0)
-
-			for j := 0; j < len(matchResult.TeamResults); j++ {
-				if i != j {
-					t2 := matchResult.TeamResults[j]
-					currentMatchDayPoints += calculatePoints(t1, t2)
-				}
-			}
-			currentEvData, ok := evRankMap[t1.Team]
-			if !ok {
-				currentEvData = evRankData{EvSum: 0.0, TotalPoints: 0, MatchCount: 0}
-			}
-
-			if len(matchResult.TeamResults) > 0 {
-				currentEvData.EvSum += currentMatchDayPoints / float64(len(matchResult.TeamResults)-1)
-			}
-			currentEvData.TotalPoints += t1.Points
+		for _, score := range roundScores(matchResult.TeamResults, points) {
+			currentEvData := evRankMap[score.Team]
+			currentEvData.EvSum += score.EvPoints
+			currentEvData.TotalPoints += score.Points
 			currentEvData.MatchCount++
-
-			evRankMap[t1.Team] = currentEvData
+			evRankMap[score.Team] = currentEvData
+		}
+		for _, team := range matchResult.Byes {
+			currentEvData := evRankMap[team]
+			currentEvData.ByeCount++
+			evRankMap[team] = currentEvData
+		}
+		for _, fixture := range matchResult.Postponed {
+			for _, team := range []string{fixture.Home.Team, fixture.Away.Team} {
+				currentEvData := evRankMap[team]
+				currentEvData.PendingCount++
+				evRankMap[team] = currentEvData
+			}
 		}
 	}
 
-	var ranks []api.Rank
+	var ranks []Rank
 	for teamName, data := range evRankMap {
-		ranks = append(ranks, api.Rank{
-			Team:     &teamName,
-			EvPoints: &data.EvSum,
-			Points:   &data.TotalPoints,
+		ranks = append(ranks, Rank{
+			Rank: api.Rank{
+				Team:     &teamName,
+				EvPoints: &data.EvSum,
+				Points:   &data.TotalPoints,
+			},
+			Played:  data.MatchCount,
+			Byes:    data.ByeCount,
+			Pending: data.PendingCount,
 		})
 	}
 
-	sort.Slice(ranks, func(i, j int) bool {
-		return *ranks[i].EvPoints > *ranks[j].EvPoints
+	// The ranks come out of a map: level teams are ordered by name, so that
+	// the ranks and the reports following them are the same on every call.
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if *ranks[i].EvPoints != *ranks[j].EvPoints {
+			return *ranks[i].EvPoints > *ranks[j].EvPoints
+		}
+		return *ranks[i].Team < *ranks[j].Team
 	})
 
 	return ranks
 }
 
+// teamRound is what a team took from a round: its actual points, the average
+// points it would have taken against every other team playing and how those
+// matches would have ended.
+type teamRound struct {
+	Team     string
+	EvPoints float64
+	Points   int
+	Wins     int
+	Draws    int
+	Losses   int
+}
+
+// roundScores scores every team playing a round, in calendar order. A team
+// would have beaten another when it takes more points from their match.
+func roundScores(teamResults []parser.TeamResult, points pointsFunc) []teamRound {
+	scores := make([]teamRound, len(teamResults))
+	for i, t1 := range teamResults {
+		scores[i] = teamRound{Team: t1.Team, Points: t1.Points}
+		currentMatchDayPoints := float64(0)
+		for j, t2 := range teamResults {
+			if i == j {
+				continue
+			}
+			ours, theirs := points(t1, t2), points(t2, t1)
+			currentMatchDayPoints += ours
+			switch {
+			case ours > theirs:
+				scores[i].Wins++
+			case ours < theirs:
+				scores[i].Losses++
+			default:
+				scores[i].Draws++
+			}
+		}
+		if len(teamResults) > 1 {
+			scores[i].EvPoints = currentMatchDayPoints / float64(len(teamResults)-1)
+		}
+	}
+	return scores
+}
+
 func calculatePoints(t1 parser.TeamResult, t2 parser.TeamResult) float64 {
-	if t1.Goals > t2.Goals {
-		return 3
-	} else if t1.Goals < t2.Goals {
-		return 0
-	} else {
-		return 1
+	return scoredPoints(scoring.Default())(t1, t2)
+}
+
+// scoredPoints compares two teams on their goals, scored with s.
+func scoredPoints(s scoring.ScoringRules) pointsFunc {
+	return func(t1 parser.TeamResult, t2 parser.TeamResult) float64 {
+		return float64(s.Points(t1.Goals, t2.Goals))
+	}
+}
+
+// neutralPoints compares two teams on the result the rules give to their
+// fantasy totals without the home bonus, scored with s. The default rules are
+// used when none are given.
+func neutralPoints(r rules.Rules, s scoring.ScoringRules) pointsFunc {
+	if len(r.Thresholds) == 0 {
+		r = rules.Default()
+	}
+	return func(t1 parser.TeamResult, t2 parser.TeamResult) float64 {
+		goals1, goals2 := r.Result(r.Neutral(t1), r.Neutral(t2))
+		return float64(s.Points(goals1, goals2))
 	}
 }
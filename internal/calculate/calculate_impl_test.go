@@ -0,0 +1,54 @@
+package calculate
+
+import (
+	"testing"
+
+	"fantalegheGO/internal/parser"
+)
+
+func TestNewResultReports(t *testing.T) {
+	a := parser.TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3}
+	b := parser.TeamResult{Team: "TeamB", Goals: 0, Points: 0}
+	results := []parser.MatchResults{
+		{
+			Matchday:    1,
+			Fixtures:    []parser.Fixture{{Home: a, Away: b, Result: "1-0"}},
+			TeamResults: []parser.TeamResult{a, b},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		opts          Options
+		wantLuck      bool
+		wantMatchdays bool
+		wantTrend     bool
+	}{
+		{name: "None Unless Asked For", opts: Options{}},
+		{name: "Luck", opts: Options{Luck: true}, wantLuck: true},
+		{name: "Matchdays", opts: Options{Matchdays: true}, wantMatchdays: true},
+		{name: "Trend", opts: Options{Trend: true}, wantTrend: true},
+		{name: "All", opts: Options{Luck: true, Matchdays: true, Trend: true}, wantLuck: true, wantMatchdays: true, wantTrend: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newResult(results, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("newResult() error = %v", err)
+			}
+			if got := result.Luck != nil; got != tt.wantLuck {
+				t.Errorf("newResult() luck = %v, want %v", got, tt.wantLuck)
+			}
+			if got := result.Matchdays != nil; got != tt.wantMatchdays {
+				t.Errorf("newResult() matchdays = %v, want %v", got, tt.wantMatchdays)
+			}
+			if got := result.Trend != nil; got != tt.wantTrend {
+				t.Errorf("newResult() trend = %v, want %v", got, tt.wantTrend)
+			}
+			if len(result.Ranks) != 2 || *result.Ranks[0].Team != "TeamA" {
+				t.Errorf("newResult() ranks = %v, want TeamA first whatever the reports", result.Ranks)
+			}
+		})
+	}
+}
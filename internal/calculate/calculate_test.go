@@ -1,14 +1,20 @@
 package calculate
 
 import (
+	"bytes"
 	"errors"
 	"fantalegheGO/internal/excel"
 	"io"
 	"mime/multipart"
+	"net/textproto"
 	"sort"
+	"strings"
 	"testing"
 
 	"fantalegheGO/internal/parser"
+	"fantalegheGO/internal/rules"
+	"fantalegheGO/internal/scoring"
+	"fantalegheGO/internal/teams"
 
 	api "github.com/antpas14/fantalegheEV-api"
 )
@@ -38,6 +44,8 @@ func (m *MockFileHeaderOpener) Size() int64 {
 type MockExcelService struct {
 	ReadExcelFunc           func(fileHeader excel.FileHeaderOpener) ([][]string, error)
 	ReadExcelFromReaderFunc func(reader io.Reader) ([][]string, error) // Added for the second method
+	ReadGridFunc            func(fileHeader excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error)
+	ReadGridFromReaderFunc  func(reader io.Reader, opts excel.Options) (*excel.Grid, error)
 }
 
 func (m *MockExcelService) ReadExcel(fileHeader excel.FileHeaderOpener) ([][]string, error) {
@@ -54,8 +62,23 @@ func (m *MockExcelService) ReadExcelFromReader(reader io.Reader) ([][]string, er
 	return nil, errors.New("ReadExcelFromReaderFunc not implemented in mock")
 }
 
+func (m *MockExcelService) ReadGrid(fileHeader excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+	if m.ReadGridFunc != nil {
+		return m.ReadGridFunc(fileHeader, opts)
+	}
+	return nil, errors.New("ReadGridFunc not implemented in mock")
+}
+
+func (m *MockExcelService) ReadGridFromReader(reader io.Reader, opts excel.Options) (*excel.Grid, error) {
+	if m.ReadGridFromReaderFunc != nil {
+		return m.ReadGridFromReaderFunc(reader, opts)
+	}
+	return nil, errors.New("ReadGridFromReaderFunc not implemented in mock")
+}
+
 type MockParser struct {
 	GetTeamResultsFunc func(excelRawData [][]string) ([]parser.MatchResults, error)
+	ParseFunc          func(excelRawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error)
 }
 
 func (m *MockParser) GetTeamResults(excelRawData [][]string) ([]parser.MatchResults, error) {
@@ -65,6 +88,13 @@ func (m *MockParser) GetTeamResults(excelRawData [][]string) ([]parser.MatchResu
 	return nil, errors.New("GetTeamResultsFunc not implemented in mock")
 }
 
+func (m *MockParser) Parse(excelRawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+	if m.ParseFunc != nil {
+		return m.ParseFunc(excelRawData, mode)
+	}
+	return nil, nil, errors.New("ParseFunc not implemented in mock")
+}
+
 // --- Test Functions ---
 
 func TestCalculatePoints(t *testing.T) {
@@ -152,7 +182,7 @@ func TestCalculate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calculate(tt.results)
+			got := APIRanks(calculate(tt.results))
 
 			sortRanks(got)
 			sortRanks(tt.want)
@@ -177,6 +207,413 @@ func TestCalculate(t *testing.T) {
 	}
 }
 
+func TestCalculateByes(t *testing.T) {
+	// Three teams: every round one of them rests.
+	results := []parser.MatchResults{
+		{
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: 1, Points: 3},
+				{Team: "TeamB", Goals: 0, Points: 0},
+			},
+			Byes: []string{"TeamC"},
+		},
+		{
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamC", Home: true, Goals: 2, Points: 3},
+				{Team: "TeamA", Goals: 1, Points: 0},
+			},
+			Byes: []string{"TeamB"},
+		},
+	}
+
+	got := calculate(results)
+	want := map[string]Rank{
+		"TeamA": {Played: 2, Byes: 0},
+		"TeamB": {Played: 1, Byes: 1},
+		"TeamC": {Played: 1, Byes: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("calculate() got %d ranks, want %d", len(got), len(want))
+	}
+	for _, r := range got {
+		if r.Played != want[*r.Team].Played || r.Byes != want[*r.Team].Byes {
+			t.Errorf("%s played %d and rested %d, want %d and %d", *r.Team, r.Played, r.Byes, want[*r.Team].Played, want[*r.Team].Byes)
+		}
+		// Resting teams are left out of the round: C beat the only team
+		// playing in its round.
+		if *r.Team == "TeamC" && *r.EvPoints != 3 {
+			t.Errorf("EvPoints mismatch for TeamC: got %f, want 3", *r.EvPoints)
+		}
+	}
+}
+
+func TestRankIncomplete(t *testing.T) {
+	a := parser.TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3}
+	b := parser.TeamResult{Team: "TeamB", Goals: 0, Points: 0}
+	c := parser.TeamResult{Team: "TeamC", Home: true, Goals: 0, Points: 1}
+	d := parser.TeamResult{Team: "TeamD", Goals: 0, Points: 1}
+	a2 := parser.TeamResult{Team: "TeamA", Home: true, Goals: 2, Points: 3}
+	c2 := parser.TeamResult{Team: "TeamC", Goals: 0, Points: 0}
+	// The second round is played in part only: B - D was postponed.
+	results := []parser.MatchResults{
+		{
+			Matchday:    1,
+			Fixtures:    []parser.Fixture{{Home: a, Away: b, Result: "1-0"}, {Home: c, Away: d, Result: "0-0"}},
+			TeamResults: []parser.TeamResult{a, b, c, d},
+		},
+		{
+			Matchday:    2,
+			Fixtures:    []parser.Fixture{{Home: a2, Away: c2, Result: "2-0"}},
+			TeamResults: []parser.TeamResult{a2, c2},
+			Postponed:   []parser.Fixture{{Home: parser.TeamResult{Team: "TeamB", Home: true}, Away: parser.TeamResult{Team: "TeamD"}}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		mode        IncompleteMode
+		evA         float64
+		playedA     int
+		pendingB    int
+		provisional bool
+	}{
+		{name: "Include", mode: IncompleteInclude, evA: 6, playedA: 2, pendingB: 1},
+		{name: "Exclude", mode: IncompleteExclude, evA: 3, playedA: 1, pendingB: 0},
+		{name: "Provisional", mode: IncompleteProvisional, evA: 6, playedA: 2, pendingB: 1, provisional: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rank(results, &parser.ParseReport{}, Options{Incomplete: tt.mode})
+			if err != nil {
+				t.Fatalf("rank() error = %v", err)
+			}
+			for _, r := range got {
+				if r.Provisional != tt.provisional {
+					t.Errorf("%s provisional = %v, want %v", *r.Team, r.Provisional, tt.provisional)
+				}
+				switch *r.Team {
+				case "TeamA":
+					if !floatEquals(*r.EvPoints, tt.evA, 1e-9) || r.Played != tt.playedA {
+						t.Errorf("TeamA got EvPoints %f over %d matches, want %f over %d", *r.EvPoints, r.Played, tt.evA, tt.playedA)
+					}
+				case "TeamB":
+					if r.Pending != tt.pendingB {
+						t.Errorf("TeamB pending = %d, want %d", r.Pending, tt.pendingB)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateTies(t *testing.T) {
+	// TeamB and TeamC draw with the same goals in both rounds: they are level
+	// and must come out in the same order on every call.
+	a := parser.TeamResult{Team: "TeamA", Home: true, Goals: 3, Points: 3}
+	b := parser.TeamResult{Team: "TeamB", Goals: 1, Points: 1}
+	c := parser.TeamResult{Team: "TeamC", Home: true, Goals: 1, Points: 1}
+	d := parser.TeamResult{Team: "TeamD", Goals: 0, Points: 0}
+	results := []parser.MatchResults{
+		{Matchday: 1, TeamResults: []parser.TeamResult{a, d, c, b}},
+		{Matchday: 2, TeamResults: []parser.TeamResult{c, b, a, d}},
+	}
+
+	want := []string{"TeamA", "TeamB", "TeamC", "TeamD"}
+	for i := 0; i < 20; i++ {
+		ranks := calculate(results)
+		var got []string
+		for _, r := range ranks {
+			got = append(got, *r.Team)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("calculate() = %v, want %v", got, want)
+		}
+
+		series := trend(ranks, results, calculatePoints)
+		if series.Teams[1].Team != "TeamB" || series.Teams[2].Team != "TeamC" {
+			t.Fatalf("trend() = %+v, want TeamB before TeamC", series.Teams)
+		}
+	}
+}
+
+func TestRankUnplayedRounds(t *testing.T) {
+	a := parser.TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3}
+	b := parser.TeamResult{Team: "TeamB", Goals: 0, Points: 0}
+	// A mid-season export: round 2 is still to be played.
+	results := []parser.MatchResults{
+		{
+			Matchday:    1,
+			Fixtures:    []parser.Fixture{{Home: a, Away: b, Result: "1-0"}},
+			TeamResults: []parser.TeamResult{a, b},
+		},
+		{
+			Matchday:    2,
+			TeamResults: []parser.TeamResult{},
+			Postponed:   []parser.Fixture{{Home: parser.TeamResult{Team: "TeamB", Home: true}, Away: parser.TeamResult{Team: "TeamA"}}},
+		},
+	}
+
+	report := &parser.ParseReport{}
+	result, err := newResult(results, report, Options{Incomplete: IncompleteProvisional, Luck: true, Matchdays: true, Trend: true})
+	if err != nil {
+		t.Fatalf("newResult() error = %v", err)
+	}
+	for _, r := range result.Ranks {
+		if r.Pending != 0 || r.Provisional {
+			t.Errorf("%s pending = %d, provisional = %v, want neither for a round not played yet", *r.Team, r.Pending, r.Provisional)
+		}
+	}
+	if len(report.Warnings) != 0 {
+		t.Errorf("newResult() warnings = %v, want none", report.Warnings)
+	}
+	if len(result.Matchdays) != 1 || len(result.Trend.Matchdays) != 1 || len(result.Luck.Teams[0].Matchdays) != 1 {
+		t.Errorf("newResult() reports = %+v, %+v, %+v, want matchday 1 alone", result.Matchdays, result.Trend, result.Luck)
+	}
+}
+
+func TestRankScoring(t *testing.T) {
+	a := parser.TeamResult{Team: "TeamA", Home: true, Goals: 4, Points: 3}
+	b := parser.TeamResult{Team: "TeamB", Goals: 1, Points: 0}
+	c := parser.TeamResult{Team: "TeamC", Home: true, Goals: 1, Points: 1}
+	d := parser.TeamResult{Team: "TeamD", Goals: 1, Points: 1}
+	results := []parser.MatchResults{
+		{
+			Matchday:    1,
+			Fixtures:    []parser.Fixture{{Home: a, Away: b, Result: "4-1"}, {Home: c, Away: d, Result: "1-1"}},
+			TeamResults: []parser.TeamResult{a, b, c, d},
+		},
+	}
+	// 2/1/0 with a bonus point for winning by 3 goals: A's win is worth 3
+	// points against B, which it beat by 3, and against C and D, which it
+	// would have beaten by 3 as well.
+	opts := Options{Scoring: scoring.ScoringRules{Win: 2, Draw: 1, BonusMargin: 3, Bonus: 1}}
+
+	got, err := rank(results, &parser.ParseReport{}, opts)
+	if err != nil {
+		t.Fatalf("rank() error = %v", err)
+	}
+	want := map[string]struct {
+		ev     float64
+		points int
+	}{
+		"TeamA": {ev: 3, points: 3},
+		"TeamB": {ev: 2.0 / 3, points: 0},
+		"TeamC": {ev: 2.0 / 3, points: 1},
+		"TeamD": {ev: 2.0 / 3, points: 1},
+	}
+	for _, r := range got {
+		if !floatEquals(*r.EvPoints, want[*r.Team].ev, 0.000001) || *r.Points != want[*r.Team].points {
+			t.Errorf("%s got EvPoints %f and %d points, want %f and %d", *r.Team, *r.EvPoints, *r.Points, want[*r.Team].ev, want[*r.Team].points)
+		}
+	}
+	if results[0].TeamResults[0].Points != 3 || results[0].Fixtures[0].Home.Points != 3 {
+		t.Errorf("rank() changed the parsed results")
+	}
+
+	opts.Scoring = scoring.ScoringRules{Win: 2, Draw: 1}
+	got, err = rank(results, &parser.ParseReport{}, opts)
+	if err != nil {
+		t.Fatalf("rank() error = %v", err)
+	}
+	for _, r := range got {
+		if *r.Team == "TeamA" && (*r.Points != 2 || !floatEquals(*r.EvPoints, 2, 0.000001)) {
+			t.Errorf("TeamA got EvPoints %f and %d points, want 2 and 2", *r.EvPoints, *r.Points)
+		}
+	}
+}
+
+func TestRankRulesReplace(t *testing.T) {
+	// The calendar gives C a 1-0 win that the rules turn into a 0-0 draw.
+	c := parser.TeamResult{Team: "TeamC", Home: true, Goals: 1, Points: 3, FantasyPoints: 64}
+	d := parser.TeamResult{Team: "TeamD", Goals: 0, Points: 0, FantasyPoints: 59}
+	results := []parser.MatchResults{
+		{
+			Matchday:    1,
+			Fixtures:    []parser.Fixture{{Home: c, Away: d, Result: "1-0"}},
+			TeamResults: []parser.TeamResult{c, d},
+		},
+	}
+
+	result, err := newResult(results, nil, Options{RulesMode: rules.ModeReplace, Rules: rules.Default()})
+	if err != nil {
+		t.Fatalf("newResult() error = %v", err)
+	}
+	for _, r := range result.Ranks {
+		if *r.Points != 1 || !floatEquals(*r.EvPoints, 1, 0.000001) {
+			t.Errorf("%s got EvPoints %f and %d points, want 1 and 1", *r.Team, *r.EvPoints, *r.Points)
+		}
+	}
+}
+
+func TestRankMatchdayRange(t *testing.T) {
+	var results []parser.MatchResults
+	for matchday := 1; matchday <= 4; matchday++ {
+		results = append(results, parser.MatchResults{
+			Matchday: matchday,
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: matchday, Points: 3},
+				{Team: "TeamB", Goals: 0, Points: 0},
+			},
+		})
+	}
+
+	tests := []struct {
+		name        string
+		from, to    int
+		played      int
+		expectedErr string
+	}{
+		{name: "Whole season", played: 4},
+		{name: "As of matchday 3", to: 3, played: 3},
+		{name: "Second half", from: 3, played: 2},
+		{name: "Single matchday", from: 2, to: 2, played: 1},
+		{name: "Past the last matchday", from: 5, played: 0},
+		{name: "Inverted range", from: 3, to: 2, expectedErr: "calculate: from matchday 3 is after to matchday 2"},
+		{name: "Negative matchday", from: -1, expectedErr: "calculate: matchdays cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rank(results, &parser.ParseReport{}, Options{From: tt.from, To: tt.to})
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Errorf("rank() error = %v, want %q", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rank() error = %v", err)
+			}
+			if tt.played == 0 {
+				if len(got) != 0 {
+					t.Errorf("rank() = %d ranks, want none", len(got))
+				}
+				return
+			}
+			for _, r := range got {
+				if r.Played != tt.played || (*r.Team == "TeamA" && *r.Points != 3*tt.played) {
+					t.Errorf("%s played %d matches for %d points, want %d matches", *r.Team, r.Played, *r.Points, tt.played)
+				}
+			}
+		})
+	}
+}
+
+func TestRankNeutral(t *testing.T) {
+	// A wins at home only thanks to the 2 points bonus: without it, it would
+	// not have beaten C or D either.
+	results := []parser.MatchResults{
+		{
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 67},
+				{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 60},
+				{Team: "TeamC", Home: true, Goals: 0, Points: 0, FantasyPoints: 64},
+				{Team: "TeamD", Goals: 1, Points: 3, FantasyPoints: 66},
+			},
+		},
+	}
+	opts := Options{EVMode: EVNeutral, Rules: rules.Rules{Thresholds: []float64{66}, Step: 6, HomeBonus: 2}}
+
+	got, err := rank(results, &parser.ParseReport{}, opts)
+	if err != nil {
+		t.Fatalf("rank() error = %v", err)
+	}
+	want := map[string]float64{"TeamA": 2.0 / 3, "TeamB": 2.0 / 3, "TeamC": 2.0 / 3, "TeamD": 3}
+	for _, r := range got {
+		if !floatEquals(*r.EvPoints, want[*r.Team], 0.000001) {
+			t.Errorf("EvPoints mismatch for %s: got %f, want %f", *r.Team, *r.EvPoints, want[*r.Team])
+		}
+		if *r.Team == "TeamA" && *r.Points != 3 {
+			t.Errorf("Points mismatch for TeamA: got %d, want the 3 points of the written result", *r.Points)
+		}
+	}
+
+	// The written goals give A the win against everyone but D.
+	got, err = rank(results, &parser.ParseReport{}, Options{})
+	if err != nil {
+		t.Fatalf("rank() error = %v", err)
+	}
+	for _, r := range got {
+		if *r.Team == "TeamA" && !floatEquals(*r.EvPoints, 7.0/3, 0.000001) {
+			t.Errorf("EvPoints mismatch for TeamA: got %f, want %f", *r.EvPoints, 7.0/3)
+		}
+	}
+}
+
+func TestRankFoldsTeams(t *testing.T) {
+	results := []parser.MatchResults{
+		{
+			TeamResults: []parser.TeamResult{
+				{Team: "FC Pippo", Goals: 1, Points: 3},
+				{Team: "Longobarda", Goals: 0, Points: 0},
+			},
+		},
+		{
+			TeamResults: []parser.TeamResult{
+				{Team: "fc pippo", Goals: 0, Points: 0},
+				{Team: "Longobarda", Goals: 2, Points: 3},
+			},
+		},
+		{
+			TeamResults: []parser.TeamResult{
+				{Team: "Pippo United", Goals: 1, Points: 1},
+				{Team: "Longobarda", Goals: 1, Points: 1},
+			},
+		},
+	}
+	aliases, err := teams.NewAliases(map[string]string{"Pippo United": "FC Pippo"})
+	if err != nil {
+		t.Fatalf("NewAliases() error = %v", err)
+	}
+
+	got, err := rank(results, &parser.ParseReport{}, Options{Aliases: aliases})
+	if err != nil {
+		t.Fatalf("rank() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("rank() got %d ranks, want 2: %v", len(got), got)
+	}
+	for _, r := range got {
+		if *r.Team == "FC Pippo" && *r.Points != 4 {
+			t.Errorf("Points mismatch for FC Pippo: got %d, want 4", *r.Points)
+		}
+	}
+	if results[1].TeamResults[0].Team != "fc pippo" {
+		t.Errorf("rank() renamed the parsed results: %v", results[1].TeamResults)
+	}
+}
+
+func TestRankValidates(t *testing.T) {
+	matchday := parser.MatchResults{
+		Matchday: 1,
+		Label:    "Giornata 1",
+		TeamResults: []parser.TeamResult{
+			{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 67},
+			{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 60},
+		},
+	}
+	copied := matchday
+	copied.Matchday, copied.Label = 2, "Giornata 2"
+	results := []parser.MatchResults{matchday, copied}
+
+	report := &parser.ParseReport{}
+	_, err := rank(results, report, Options{Mode: parser.ModeStrict})
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || report.Errors[0].Code != parser.CodeDuplicateRound {
+		t.Fatalf("rank() error = %v, want a duplicate round error", err)
+	}
+
+	report = &parser.ParseReport{}
+	got, err := rank(results, report, Options{Mode: parser.ModeLenient})
+	if err != nil {
+		t.Fatalf("rank() error = %v", err)
+	}
+	if len(got) != 2 || len(report.Warnings) != 1 || report.Warnings[0].Code != parser.CodeDuplicateRound {
+		t.Errorf("rank() ranks = %v, warnings = %v, want both teams and a duplicate round warning", got, report.Warnings)
+	}
+}
+
 func TestGetRanks(t *testing.T) {
 	mockFileHeader := &multipart.FileHeader{
 		Filename: "test.xlsx",
@@ -188,18 +625,23 @@ func TestGetRanks(t *testing.T) {
 		name             string
 		mockExcelService *MockExcelService
 		mockParser       *MockParser
+		opts             Options
 		want             []api.Rank
 		wantErr          bool
+		wantParse        bool
 	}{
 		{
 			name: "Successful read and parse",
 			mockExcelService: &MockExcelService{
-				ReadExcelFunc: func(fh excel.FileHeaderOpener) ([][]string, error) {
-					return [][]string{{"data"}}, nil // Succeeds
+				ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+					return &excel.Grid{Rows: [][]excel.Cell{{{Ref: "A1", Row: 1, Column: 1, Value: "data"}}}}, nil // Succeeds
 				},
 			},
 			mockParser: &MockParser{
-				GetTeamResultsFunc: func(rawData [][]string) ([]parser.MatchResults, error) {
+				ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+					if len(rawData) != 1 || rawData[0][0] != "data" {
+						return nil, nil, errors.New("expected the grid values")
+					}
 					return []parser.MatchResults{
 						{
 							TeamResults: []parser.TeamResult{
@@ -217,7 +659,7 @@ func TestGetRanks(t *testing.T) {
 								{Team: "TeamD", Goals: 3, Points: 3},
 							},
 						},
-					}, nil
+					}, &parser.ParseReport{}, nil
 				},
 			},
 			want: []api.Rank{
@@ -229,15 +671,15 @@ func TestGetRanks(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "Excel service ReadExcel error",
+			name: "Excel service ReadGrid error",
 			mockExcelService: &MockExcelService{
-				ReadExcelFunc: func(fh excel.FileHeaderOpener) ([][]string, error) {
-					return [][]string{{"data"}}, errors.New("there is an error") // Succeeds
+				ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+					return nil, errors.New("there is an error")
 				},
 			},
 			mockParser: &MockParser{ // Parser mock won't be called, but needs to be there
-				GetTeamResultsFunc: func(rawData [][]string) ([]parser.MatchResults, error) {
-					return nil, nil // Should not be called
+				ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+					return nil, nil, nil // Should not be called
 				},
 			},
 			want:    nil,
@@ -246,13 +688,13 @@ func TestGetRanks(t *testing.T) {
 		{
 			name: "Parser GetTeamResults error",
 			mockExcelService: &MockExcelService{
-				ReadExcelFunc: func(fh excel.FileHeaderOpener) ([][]string, error) {
-					return [][]string{{"data"}}, nil // Succeeds
+				ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+					return &excel.Grid{Rows: [][]excel.Cell{{{Ref: "A1", Row: 1, Column: 1, Value: "data"}}}}, nil // Succeeds
 				},
 			},
 			mockParser: &MockParser{
-				GetTeamResultsFunc: func(rawData [][]string) ([]parser.MatchResults, error) {
-					return nil, errors.New("parser error")
+				ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+					return nil, &parser.ParseReport{}, errors.New("parser error")
 				},
 			},
 			want:    nil,
@@ -261,31 +703,77 @@ func TestGetRanks(t *testing.T) {
 		{
 			name: "No team results returned by parser",
 			mockExcelService: &MockExcelService{
-				ReadExcelFunc: func(fh excel.FileHeaderOpener) ([][]string, error) {
-					return [][]string{{"data"}}, nil // Succeeds
+				ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+					return &excel.Grid{Rows: [][]excel.Cell{{{Ref: "A1", Row: 1, Column: 1, Value: "data"}}}}, nil // Succeeds
+				},
+			},
+			mockParser: &MockParser{
+				ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+					return []parser.MatchResults{}, &parser.ParseReport{}, nil // Empty results
+				},
+			},
+			want:    []api.Rank{},
+			wantErr: false,
+		},
+		{
+			name: "Sheet is passed to the excel service",
+			mockExcelService: &MockExcelService{
+				ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+					if opts.Sheet != "Calendario" {
+						return nil, errors.New("expected the Calendario sheet")
+					}
+					return &excel.Grid{Sheet: opts.Sheet}, nil
 				},
 			},
 			mockParser: &MockParser{
-				GetTeamResultsFunc: func(rawData [][]string) ([]parser.MatchResults, error) {
-					return []parser.MatchResults{}, nil // Empty results
+				ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+					return []parser.MatchResults{}, &parser.ParseReport{}, nil
 				},
 			},
+			opts:    Options{Sheet: "Calendario"},
 			want:    []api.Rank{},
 			wantErr: false,
 		},
+		{
+			name: "Strict mode is passed to the parser",
+			mockExcelService: &MockExcelService{
+				ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+					return &excel.Grid{Rows: [][]excel.Cell{{{Ref: "A1", Row: 1, Column: 1, Value: "data"}}}}, nil // Succeeds
+				},
+			},
+			mockParser: &MockParser{
+				ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+					if mode != parser.ModeStrict {
+						return nil, nil, errors.New("expected strict mode")
+					}
+					report := &parser.ParseReport{Errors: []parser.Diagnostic{{Row: 1, Column: 2, Code: parser.CodeInvalidFantasyPoints}}}
+					return nil, report, &parser.ParseError{Report: report}
+				},
+			},
+			opts:      Options{Mode: parser.ModeStrict},
+			want:      nil,
+			wantErr:   true,
+			wantParse: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			calcImpl := NewCalculateImpl(tt.mockExcelService, tt.mockParser)
-			got, err := calcImpl.GetRanks(mockFileHeader)
+			result, err := calcImpl.GetRanks(mockFileHeader, tt.opts)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetRanks() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
+			var parseErr *parser.ParseError
+			if errors.As(err, &parseErr) != tt.wantParse {
+				t.Errorf("GetRanks() error = %v, want *parser.ParseError %v", err, tt.wantParse)
+			}
+
 			if !tt.wantErr {
+				got := APIRanks(result.Ranks)
 				sortRanks(got)
 				sortRanks(tt.want)
 
@@ -310,8 +798,196 @@ func TestGetRanks(t *testing.T) {
 	}
 }
 
+func TestGetRanksNoFile(t *testing.T) {
+	calcImpl := NewCalculateImpl(&MockExcelService{}, &MockParser{})
+	if _, err := calcImpl.GetRanks(nil, Options{}); err == nil {
+		t.Errorf("GetRanks() error = nil, want an error for a missing file")
+	}
+}
+
+func TestGetRanksReaderRouting(t *testing.T) {
+	readerFor := func(name string, used *string) *MockExcelService {
+		return &MockExcelService{
+			ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+				*used = name
+				return &excel.Grid{}, nil
+			},
+		}
+	}
+	mockParser := &MockParser{
+		ParseFunc: func(rawData [][]string, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+			return []parser.MatchResults{}, &parser.ParseReport{}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		fileHeader *multipart.FileHeader
+		want       string
+	}{
+		{name: "Excel upload", fileHeader: &multipart.FileHeader{Filename: "calendario.xlsx"}, want: "xlsx"},
+		{name: "CSV upload", fileHeader: &multipart.FileHeader{Filename: "calendario.csv"}, want: "csv"},
+		{
+			name: "CSV content type",
+			fileHeader: &multipart.FileHeader{
+				Filename: "calendario",
+				Header:   textproto.MIMEHeader{"Content-Type": {"text/csv"}},
+			},
+			want: "csv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := ""
+			calcImpl := NewCalculateImpl(readerFor("xlsx", &used), mockParser).
+				WithReader(excel.FormatCSV, readerFor("csv", &used))
+
+			if _, err := calcImpl.GetRanks(tt.fileHeader, Options{}); err != nil {
+				t.Fatalf("GetRanks() error = %v", err)
+			}
+			if used != tt.want {
+				t.Errorf("GetRanks() read the upload with the %s reader, want %s", used, tt.want)
+			}
+		})
+	}
+}
+
+type MockHTMLParser struct {
+	ParseHTMLFunc func(reader io.Reader, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error)
+}
+
+func (m *MockHTMLParser) ParseHTML(reader io.Reader, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+	if m.ParseHTMLFunc != nil {
+		return m.ParseHTMLFunc(reader, mode)
+	}
+	return nil, nil, errors.New("ParseHTMLFunc not implemented in mock")
+}
+
+func TestGetRanksFromHTML(t *testing.T) {
+	page := "<!DOCTYPE html><html><body><table></table></body></html>"
+	fileHeader := uploadedFile(t, "calendario", page)
+
+	htmlParser := &MockHTMLParser{
+		ParseHTMLFunc: func(reader io.Reader, mode parser.Mode) ([]parser.MatchResults, *parser.ParseReport, error) {
+			body, err := io.ReadAll(reader)
+			if err != nil || string(body) != page {
+				return nil, nil, errors.New("expected the uploaded page")
+			}
+			if mode != parser.ModeStrict {
+				return nil, nil, errors.New("expected strict mode")
+			}
+			return []parser.MatchResults{
+				{TeamResults: []parser.TeamResult{{Team: "TeamA", Goals: 2, Points: 3}, {Team: "TeamB", Goals: 1, Points: 0}}},
+			}, &parser.ParseReport{Layout: "single"}, nil
+		},
+	}
+	excelService := &MockExcelService{
+		ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+			return nil, errors.New("the page should not be read as a workbook")
+		},
+	}
+
+	calcImpl := NewCalculateImpl(excelService, &MockParser{}).WithHTMLParser(htmlParser)
+	result, err := calcImpl.GetRanks(fileHeader, Options{Mode: parser.ModeStrict})
+	if err != nil {
+		t.Fatalf("GetRanks() error = %v", err)
+	}
+
+	if len(result.Ranks) != 2 || *result.Ranks[0].Team != "TeamA" || *result.Ranks[0].EvPoints != 3 {
+		t.Errorf("GetRanks() ranks = %v, want TeamA first with 3 EV points", result.Ranks)
+	}
+	if result.Sheet != "" || result.Report.Layout != "single" {
+		t.Errorf("GetRanks() sheet = %q, layout = %q, want no sheet and the page layout", result.Sheet, result.Report.Layout)
+	}
+}
+
+func TestGetRanksFromCalendar(t *testing.T) {
+	calendar := `{"matchdays": [{"matchday": 1, "fixtures": [
+		{"home": {"team": "TeamA", "fantasyPoints": 72, "goals": 2}, "away": {"team": "TeamB", "fantasyPoints": 60, "goals": 0}}
+	]}]}`
+	excelService := &MockExcelService{
+		ReadGridFunc: func(fh excel.FileHeaderOpener, opts excel.Options) (*excel.Grid, error) {
+			return nil, errors.New("a JSON calendar should not be read as a workbook")
+		},
+	}
+
+	calcImpl := NewCalculateImpl(excelService, &MockParser{})
+	result, err := calcImpl.GetRanksFromCalendar(strings.NewReader(calendar), Options{})
+	if err != nil {
+		t.Fatalf("GetRanksFromCalendar() error = %v", err)
+	}
+	if len(result.Ranks) != 2 || *result.Ranks[0].Team != "TeamA" || *result.Ranks[0].Points != 3 {
+		t.Errorf("GetRanksFromCalendar() ranks = %v, want TeamA first with 3 points", result.Ranks)
+	}
+	if result.Report.Layout != "json" {
+		t.Errorf("GetRanksFromCalendar() layout = %q, want json", result.Report.Layout)
+	}
+
+	// The written 2-0 is a 1-0 with a goal every 10 points: still a win, but
+	// the check reports it.
+	checked := Options{RulesMode: rules.ModeCheck, Rules: rules.Rules{Thresholds: []float64{66}, Step: 10}}
+	result, err = calcImpl.GetRanksFromCalendar(strings.NewReader(calendar), checked)
+	if err != nil {
+		t.Fatalf("GetRanksFromCalendar() error = %v", err)
+	}
+	if len(result.Report.Warnings) != 1 || result.Report.Warnings[0].Code != rules.CodeResultMismatch {
+		t.Errorf("GetRanksFromCalendar() warnings = %v, want one result mismatch", result.Report.Warnings)
+	}
+
+	_, err = calcImpl.GetRanksFromCalendar(strings.NewReader(`{"matchdays": []}`), Options{})
+	var parseErr *parser.ParseError
+	if !errors.As(err, &parseErr) || errors.Is(err, ErrInconsistentCalendar) {
+		t.Errorf("GetRanksFromCalendar() error = %v, want a schema *parser.ParseError", err)
+	}
+
+	// TeamA playing twice in a round matches the schema but fails validation.
+	duplicated := `{"matchdays": [{"matchday": 1, "fixtures": [
+		{"home": {"team": "TeamA", "fantasyPoints": 72, "goals": 2}, "away": {"team": "TeamB", "fantasyPoints": 60, "goals": 0}},
+		{"home": {"team": "TeamA", "fantasyPoints": 72, "goals": 2}, "away": {"team": "TeamC", "fantasyPoints": 60, "goals": 0}}
+	]}]}`
+	_, err = calcImpl.GetRanksFromCalendar(strings.NewReader(duplicated), Options{Mode: parser.ModeStrict})
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrInconsistentCalendar) {
+		t.Errorf("GetRanksFromCalendar() error = %v, want an inconsistent calendar", err)
+	}
+}
+
 // Helper functions
 
+// uploadedFile returns the file header of a file uploaded as a form field.
+func uploadedFile(t *testing.T, filename, content string) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// rank returns the ranks of newResult.
+func rank(results []parser.MatchResults, report *parser.ParseReport, opts Options) ([]Rank, error) {
+	result, err := newResult(results, report, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Ranks, nil
+}
+
 func sortRanks(ranks []api.Rank) {
 	sort.Slice(ranks, func(i, j int) bool {
 		// Primary sort by EvPoints (desc), secondary by Team (asc) for tie-breaking
@@ -0,0 +1,149 @@
+package calculate
+
+import (
+	"math"
+	"sort"
+
+	"fantalegheGO/internal/parser"
+)
+
+// LuckReport tells which teams took more points than their play was worth
+// and which took fewer.
+type LuckReport struct {
+	// Teams are sorted from the luckiest to the unluckiest.
+	Teams []Luck `json:"teams"`
+	// RankCorrelation is the Spearman correlation between the actual and the
+	// expected value standings: 1 when they are the same, lower as luck
+	// reshuffles the table, down to -1 when one is the other upside down. It
+	// is 0 when either standing has every team level.
+	RankCorrelation float64 `json:"rankCorrelation"`
+}
+
+// Luck is the luck of a single team. Delta is its actual points less its
+// expected ones, positive for a lucky team.
+type Luck struct {
+	Team     string  `json:"team"`
+	Points   int     `json:"points"`
+	EvPoints float64 `json:"evPoints"`
+	Delta    float64 `json:"delta"`
+	// Rank is the position of the team in the actual standings, EvRank the
+	// one it would have on expected value. Level teams share a position.
+	Rank   int `json:"rank"`
+	EvRank int `json:"evRank"`
+	// Matchdays are the delta of the team after each ranked round.
+	Matchdays []MatchdayLuck `json:"matchdays"`
+}
+
+// MatchdayLuck is the delta a team had gathered once a round was played.
+type MatchdayLuck struct {
+	Matchday int     `json:"matchday"`
+	Delta    float64 `json:"delta"`
+}
+
+// luck compares the actual and expected points of the ranked teams, round by
+// round.
+func luck(ranks []Rank, results []parser.MatchResults, points pointsFunc) *LuckReport {
+	actual := make([]float64, len(ranks))
+	expected := make([]float64, len(ranks))
+	for i, r := range ranks {
+		actual[i], expected[i] = float64(*r.Points), *r.EvPoints
+	}
+	actualRanks, evRanks := standing(actual), standing(expected)
+
+	report := &LuckReport{
+		Teams:           make([]Luck, len(ranks)),
+		RankCorrelation: correlation(averageRanks(actual), averageRanks(expected)),
+	}
+	index := make(map[string]int, len(ranks))
+	for i, r := range ranks {
+		index[*r.Team] = i
+		report.Teams[i] = Luck{
+			Team:     *r.Team,
+			Points:   *r.Points,
+			EvPoints: *r.EvPoints,
+			Delta:    float64(*r.Points) - *r.EvPoints,
+			Rank:     actualRanks[i],
+			EvRank:   evRanks[i],
+		}
+	}
+
+	delta := make([]float64, len(ranks))
+	for _, matchResults := range results {
+		for _, score := range roundScores(matchResults.TeamResults, points) {
+			if i, ok := index[score.Team]; ok {
+				delta[i] += float64(score.Points) - score.EvPoints
+			}
+		}
+		for i := range report.Teams {
+			report.Teams[i].Matchdays = append(report.Teams[i].Matchdays, MatchdayLuck{Matchday: matchResults.Matchday, Delta: delta[i]})
+		}
+	}
+
+	sort.SliceStable(report.Teams, func(i, j int) bool {
+		if report.Teams[i].Delta != report.Teams[j].Delta {
+			return report.Teams[i].Delta > report.Teams[j].Delta
+		}
+		return report.Teams[i].Team < report.Teams[j].Team
+	})
+	return report
+}
+
+// standing returns the 1-based position of every value, highest first. Equal
+// values share the best position, as in 1, 2, 2, 4.
+func standing(values []float64) []int {
+	positions := make([]int, len(values))
+	for i, value := range values {
+		positions[i] = 1
+		for _, other := range values {
+			if other > value {
+				positions[i]++
+			}
+		}
+	}
+	return positions
+}
+
+// averageRanks ranks the values highest first, giving equal values the
+// average of the positions they span, as the Spearman correlation needs.
+func averageRanks(values []float64) []float64 {
+	ranks := make([]float64, len(values))
+	for i, value := range values {
+		higher, equal := 0, 0
+		for _, other := range values {
+			if other > value {
+				higher++
+			} else if other == value {
+				equal++
+			}
+		}
+		ranks[i] = float64(higher) + float64(equal+1)/2
+	}
+	return ranks
+}
+
+// correlation returns the Pearson correlation of two series, 0 when either
+// does not vary.
+func correlation(x, y []float64) float64 {
+	if len(x) < 2 {
+		return 0
+	}
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(len(x))
+	meanY /= float64(len(y))
+
+	var covariance, varianceX, varianceY float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		covariance += dx * dy
+		varianceX += dx * dx
+		varianceY += dy * dy
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0
+	}
+	return covariance / math.Sqrt(varianceX*varianceY)
+}
@@ -0,0 +1,80 @@
+package calculate
+
+import (
+	"math"
+	"testing"
+
+	"fantalegheGO/internal/parser"
+)
+
+func TestLuck(t *testing.T) {
+	results := []parser.MatchResults{
+		{
+			Matchday: 1,
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: 1, Points: 3},
+				{Team: "TeamB", Goals: 0, Points: 0},
+				{Team: "TeamC", Home: true, Goals: 0, Points: 1},
+				{Team: "TeamD", Goals: 0, Points: 1},
+			},
+		},
+		{
+			Matchday: 2,
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: 0, Points: 0},
+				{Team: "TeamC", Goals: 1, Points: 3},
+				{Team: "TeamB", Home: true, Goals: 2, Points: 3},
+				{Team: "TeamD", Goals: 0, Points: 0},
+			},
+		},
+	}
+
+	got := luck(calculate(results), results, calculatePoints)
+
+	want := []Luck{
+		{Team: "TeamC", Points: 4, EvPoints: 8.0 / 3, Delta: 4.0 / 3, Rank: 1, EvRank: 3},
+		{Team: "TeamD", Points: 1, EvPoints: 1, Delta: 0, Rank: 4, EvRank: 4},
+		{Team: "TeamA", Points: 3, EvPoints: 10.0 / 3, Delta: -1.0 / 3, Rank: 2, EvRank: 2},
+		{Team: "TeamB", Points: 3, EvPoints: 11.0 / 3, Delta: -2.0 / 3, Rank: 2, EvRank: 1},
+	}
+	if len(got.Teams) != len(want) {
+		t.Fatalf("luck() got %d teams, want %d", len(got.Teams), len(want))
+	}
+	for i, w := range want {
+		g := got.Teams[i]
+		if g.Team != w.Team || g.Points != w.Points || g.Rank != w.Rank || g.EvRank != w.EvRank ||
+			!floatEquals(g.EvPoints, w.EvPoints, 0.000001) || !floatEquals(g.Delta, w.Delta, 0.000001) {
+			t.Errorf("luck() team %d = %+v, want %+v", i, g, w)
+		}
+		if len(g.Matchdays) != 2 || !floatEquals(g.Matchdays[1].Delta, w.Delta, 0.000001) {
+			t.Errorf("luck() %s matchdays = %+v, want 2 ending at %f", g.Team, g.Matchdays, w.Delta)
+		}
+	}
+	if c := got.Teams[0].Matchdays[0]; c.Matchday != 1 || !floatEquals(c.Delta, 1.0/3, 0.000001) {
+		t.Errorf("luck() TeamC after matchday 1 = %+v, want a delta of 1/3", c)
+	}
+	if want := 1.5 / math.Sqrt(22.5); !floatEquals(got.RankCorrelation, want, 0.000001) {
+		t.Errorf("luck() rank correlation = %f, want %f", got.RankCorrelation, want)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y []float64
+		want float64
+	}{
+		{name: "Same standings", x: []float64{1, 2, 3}, y: []float64{1, 2, 3}, want: 1},
+		{name: "Reversed standings", x: []float64{1, 2, 3}, y: []float64{3, 2, 1}, want: -1},
+		{name: "Every team level", x: []float64{1, 2, 3}, y: []float64{2, 2, 2}, want: 0},
+		{name: "Single team", x: []float64{1}, y: []float64{1}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := correlation(tt.x, tt.y); !floatEquals(got, tt.want, 0.000001) {
+				t.Errorf("correlation() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
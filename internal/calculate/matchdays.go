@@ -0,0 +1,73 @@
+package calculate
+
+import (
+	"sort"
+
+	"fantalegheGO/internal/parser"
+)
+
+// Matchday is the expected value breakdown of a single round.
+type Matchday struct {
+	Matchday int    `json:"matchday"`
+	Label    string `json:"label,omitempty"`
+	// Teams are the teams playing the round, best expected value first.
+	Teams []MatchdayTeam `json:"teams"`
+}
+
+// MatchdayTeam is what a team took from a round. Wins, Draws and Losses are
+// how its matches against every other team playing the round would have
+// ended, as in "you would have beaten 8 of 9 teams this week".
+type MatchdayTeam struct {
+	Team     string  `json:"team"`
+	EvPoints float64 `json:"evPoints"`
+	Points   int     `json:"points"`
+	Wins     int     `json:"wins"`
+	Draws    int     `json:"draws"`
+	Losses   int     `json:"losses"`
+	// Percentile is the share of the other teams of the round the team would
+	// have beaten, draws counting half, from 0 to 100.
+	Percentile float64 `json:"percentile"`
+}
+
+// Matchdays breaks the expected value of a parsed calendar down round by
+// round, with the same options as a ranking request. Validation findings are
+// left out; an inconsistent calendar fails with a *parser.ParseError in
+// strict mode.
+func Matchdays(results []parser.MatchResults, opts Options) ([]Matchday, error) {
+	results, points, err := prepare(results, &parser.ParseReport{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return matchdays(results, points), nil
+}
+
+func matchdays(results []parser.MatchResults, points pointsFunc) []Matchday {
+	breakdown := make([]Matchday, 0, len(results))
+	for _, matchResults := range results {
+		scores := roundScores(matchResults.TeamResults, points)
+		matchday := Matchday{
+			Matchday: matchResults.Matchday,
+			Label:    matchResults.Label,
+			Teams:    make([]MatchdayTeam, len(scores)),
+		}
+		for i, score := range scores {
+			team := MatchdayTeam{
+				Team:     score.Team,
+				EvPoints: score.EvPoints,
+				Points:   score.Points,
+				Wins:     score.Wins,
+				Draws:    score.Draws,
+				Losses:   score.Losses,
+			}
+			if others := score.Wins + score.Draws + score.Losses; others > 0 {
+				team.Percentile = 100 * (float64(score.Wins) + float64(score.Draws)/2) / float64(others)
+			}
+			matchday.Teams[i] = team
+		}
+		sort.SliceStable(matchday.Teams, func(i, j int) bool {
+			return matchday.Teams[i].EvPoints > matchday.Teams[j].EvPoints
+		})
+		breakdown = append(breakdown, matchday)
+	}
+	return breakdown
+}
@@ -0,0 +1,52 @@
+package calculate
+
+import (
+	"errors"
+	"testing"
+
+	"fantalegheGO/internal/parser"
+)
+
+func TestMatchdays(t *testing.T) {
+	a := parser.TeamResult{Team: "TeamA", Home: true, Goals: 0, Points: 0}
+	c := parser.TeamResult{Team: "TeamC", Goals: 1, Points: 3}
+	b := parser.TeamResult{Team: "TeamB", Home: true, Goals: 2, Points: 3}
+	d := parser.TeamResult{Team: "TeamD", Goals: 0, Points: 0}
+	results := []parser.MatchResults{
+		{
+			Matchday:    2,
+			Label:       "2a Giornata lega",
+			Fixtures:    []parser.Fixture{{Home: a, Away: c, Result: "0-1"}, {Home: b, Away: d, Result: "2-0"}},
+			TeamResults: []parser.TeamResult{a, c, b, d},
+		},
+	}
+
+	got, err := Matchdays(results, Options{})
+	if err != nil {
+		t.Fatalf("Matchdays() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Matchday != 2 || got[0].Label != "2a Giornata lega" {
+		t.Fatalf("Matchdays() = %+v, want matchday 2 alone", got)
+	}
+	want := []MatchdayTeam{
+		{Team: "TeamB", EvPoints: 3, Points: 3, Wins: 3, Percentile: 100},
+		{Team: "TeamC", EvPoints: 2, Points: 3, Wins: 2, Losses: 1, Percentile: 200.0 / 3},
+		{Team: "TeamA", EvPoints: 1.0 / 3, Points: 0, Draws: 1, Losses: 2, Percentile: 50.0 / 3},
+		{Team: "TeamD", EvPoints: 1.0 / 3, Points: 0, Draws: 1, Losses: 2, Percentile: 50.0 / 3},
+	}
+	for i, w := range want {
+		g := got[0].Teams[i]
+		if g.Team != w.Team || g.Points != w.Points || g.Wins != w.Wins || g.Draws != w.Draws || g.Losses != w.Losses ||
+			!floatEquals(g.EvPoints, w.EvPoints, 0.000001) || !floatEquals(g.Percentile, w.Percentile, 0.000001) {
+			t.Errorf("Matchdays() team %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	// A team playing twice makes the calendar inconsistent.
+	results[0].TeamResults = append(results[0].TeamResults, a)
+	results[0].Fixtures = append(results[0].Fixtures, parser.Fixture{Home: a, Away: d, Result: "0-0"})
+	var parseErr *parser.ParseError
+	if _, err := Matchdays(results, Options{Mode: parser.ModeStrict}); !errors.As(err, &parseErr) {
+		t.Errorf("Matchdays() error = %v, want a *parser.ParseError", err)
+	}
+}
@@ -0,0 +1,85 @@
+package calculate
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"fantalegheGO/internal/parser"
+)
+
+// Trend is how the standings moved over the season, laid out for charting:
+// the series of every team have a value for each of Matchdays.
+type Trend struct {
+	Matchdays []int        `json:"matchdays"`
+	Teams     []TeamSeries `json:"teams"`
+}
+
+// TeamSeries are the cumulative points and expected value of a team after
+// each matchday, with its position in the actual and expected value
+// standings. Level teams share a position.
+type TeamSeries struct {
+	Team     string    `json:"team"`
+	Points   []int     `json:"points"`
+	EvPoints []float64 `json:"evPoints"`
+	Rank     []int     `json:"rank"`
+	EvRank   []int     `json:"evRank"`
+}
+
+// trend accumulates the rounds for the ranked teams, in the order of ranks.
+func trend(ranks []Rank, results []parser.MatchResults, points pointsFunc) *Trend {
+	t := &Trend{Matchdays: make([]int, 0, len(results)), Teams: make([]TeamSeries, len(ranks))}
+	index := make(map[string]int, len(ranks))
+	for i, r := range ranks {
+		index[*r.Team] = i
+		t.Teams[i].Team = *r.Team
+	}
+
+	actual := make([]float64, len(ranks))
+	expected := make([]float64, len(ranks))
+	for _, matchResults := range results {
+		for _, score := range roundScores(matchResults.TeamResults, points) {
+			if i, ok := index[score.Team]; ok {
+				actual[i] += float64(score.Points)
+				expected[i] += score.EvPoints
+			}
+		}
+
+		t.Matchdays = append(t.Matchdays, matchResults.Matchday)
+		actualRanks, evRanks := standing(actual), standing(expected)
+		for i := range t.Teams {
+			series := &t.Teams[i]
+			series.Points = append(series.Points, int(actual[i]))
+			series.EvPoints = append(series.EvPoints, expected[i])
+			series.Rank = append(series.Rank, actualRanks[i])
+			series.EvRank = append(series.EvRank, evRanks[i])
+		}
+	}
+	return t
+}
+
+// WriteCSV writes the trend as a CSV table with a row for every team after
+// every matchday.
+func (t *Trend) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"matchday", "team", "points", "evPoints", "rank", "evRank"}); err != nil {
+		return err
+	}
+	for m, matchday := range t.Matchdays {
+		for _, series := range t.Teams {
+			record := []string{
+				strconv.Itoa(matchday),
+				series.Team,
+				strconv.Itoa(series.Points[m]),
+				strconv.FormatFloat(series.EvPoints[m], 'f', -1, 64),
+				strconv.Itoa(series.Rank[m]),
+				strconv.Itoa(series.EvRank[m]),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,84 @@
+package calculate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"fantalegheGO/internal/parser"
+)
+
+func TestTrend(t *testing.T) {
+	// TeamC leads after a win while TeamA and TeamB draw, then TeamA overtakes
+	// it by beating it.
+	results := []parser.MatchResults{
+		{
+			Matchday: 1,
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: 2, Points: 1},
+				{Team: "TeamB", Goals: 2, Points: 1},
+				{Team: "TeamC", Home: true, Goals: 3, Points: 3},
+				{Team: "TeamD", Goals: 1, Points: 0},
+			},
+		},
+		{
+			Matchday: 2,
+			TeamResults: []parser.TeamResult{
+				{Team: "TeamA", Home: true, Goals: 1, Points: 3},
+				{Team: "TeamC", Goals: 0, Points: 0},
+				{Team: "TeamB", Home: true, Goals: 0, Points: 1},
+				{Team: "TeamD", Goals: 0, Points: 1},
+			},
+		},
+	}
+
+	got := trend(calculate(results), results, calculatePoints)
+
+	if !reflect.DeepEqual(got.Matchdays, []int{1, 2}) {
+		t.Errorf("trend() matchdays = %v, want [1 2]", got.Matchdays)
+	}
+	want := []TeamSeries{
+		{Team: "TeamA", Points: []int{1, 4}, EvPoints: []float64{4.0 / 3, 13.0 / 3}, Rank: []int{2, 1}, EvRank: []int{2, 1}},
+		{Team: "TeamC", Points: []int{3, 3}, EvPoints: []float64{3, 11.0 / 3}, Rank: []int{1, 2}, EvRank: []int{1, 2}},
+		{Team: "TeamB", Points: []int{1, 2}, EvPoints: []float64{4.0 / 3, 2}, Rank: []int{2, 3}, EvRank: []int{2, 3}},
+		{Team: "TeamD", Points: []int{0, 1}, EvPoints: []float64{0, 2.0 / 3}, Rank: []int{4, 4}, EvRank: []int{4, 4}},
+	}
+	if len(got.Teams) != len(want) {
+		t.Fatalf("trend() teams = %+v, want %d teams", got.Teams, len(want))
+	}
+	for i, w := range want {
+		series := got.Teams[i]
+		if series.Team != w.Team || !reflect.DeepEqual(series.Points, w.Points) || !reflect.DeepEqual(series.Rank, w.Rank) || !reflect.DeepEqual(series.EvRank, w.EvRank) {
+			t.Errorf("trend() team %d = %+v, want %+v", i, series, w)
+		}
+		for m := range w.EvPoints {
+			if !floatEquals(series.EvPoints[m], w.EvPoints[m], 0.000001) {
+				t.Errorf("trend() %s EvPoints = %v, want %v", series.Team, series.EvPoints, w.EvPoints)
+				break
+			}
+		}
+	}
+}
+
+func TestTrend_WriteCSV(t *testing.T) {
+	trend := &Trend{
+		Matchdays: []int{1, 2},
+		Teams: []TeamSeries{
+			{Team: "TeamA", Points: []int{3, 4}, EvPoints: []float64{2.5, 3}, Rank: []int{1, 1}, EvRank: []int{1, 2}},
+			{Team: "Real, Madrink", Points: []int{0, 3}, EvPoints: []float64{0.5, 3.5}, Rank: []int{2, 2}, EvRank: []int{2, 1}},
+		},
+	}
+
+	var b strings.Builder
+	if err := trend.WriteCSV(&b); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	expected := "matchday,team,points,evPoints,rank,evRank\n" +
+		"1,TeamA,3,2.5,1,1\n" +
+		"1,\"Real, Madrink\",0,0.5,2,2\n" +
+		"2,TeamA,4,3,1,2\n" +
+		"2,\"Real, Madrink\",3,3.5,2,1\n"
+	if b.String() != expected {
+		t.Errorf("WriteCSV() = %q, want %q", b.String(), expected)
+	}
+}
@@ -0,0 +1,204 @@
+package excel
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvDelimiters are the separators tried on a CSV file: Italian Excel
+// exports use ';' since ',' is their decimal separator.
+var csvDelimiters = []rune{';', ',', '\t'}
+
+// csvSniffLines is how many lines are looked at to pick the delimiter.
+const csvSniffLines = 20
+
+// CSVServiceImpl reads calendars kept as CSV files. A CSV file is a single
+// sheet, so the sheet options are ignored.
+type CSVServiceImpl struct{}
+
+func NewCSVService() *CSVServiceImpl {
+	return &CSVServiceImpl{}
+}
+
+func (cs *CSVServiceImpl) ReadExcelFromReader(reader io.Reader) ([][]string, error) {
+	records, err := readCSV(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredRows [][]string
+	for _, record := range records {
+		var newRow []string
+		for _, str := range record {
+			if str != "" {
+				newRow = append(newRow, str)
+			}
+		}
+		if len(newRow) > 0 {
+			filteredRows = append(filteredRows, newRow)
+		}
+	}
+
+	if len(filteredRows) == 0 {
+		return nil, fmt.Errorf("excel: file contains no valid data rows after filtering")
+	}
+
+	return filteredRows, nil
+}
+
+func (cs *CSVServiceImpl) ReadExcel(fileHeader FileHeaderOpener) ([][]string, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return cs.ReadExcelFromReader(file)
+}
+
+// ReadGridFromReader reads the CSV file in grid mode. CSV carries no types,
+// so every non-empty cell is a string.
+func (cs *CSVServiceImpl) ReadGridFromReader(reader io.Reader, opts Options) (*Grid, error) {
+	records, err := readCSV(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	grid := &Grid{}
+	hasData := false
+	for r, record := range records {
+		grid.Rows = append(grid.Rows, textCells(r+1, record))
+		for _, value := range record {
+			if value != "" {
+				hasData = true
+			}
+		}
+	}
+
+	if !hasData {
+		return nil, fmt.Errorf("excel: file contains no valid data rows after filtering")
+	}
+
+	return grid, nil
+}
+
+func (cs *CSVServiceImpl) ReadGrid(fileHeader FileHeaderOpener, opts Options) (*Grid, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return cs.ReadGridFromReader(file, opts)
+}
+
+// readCSV decodes the file to UTF-8 and splits it with the delimiter it
+// appears to use.
+func readCSV(reader io.Reader) ([][]string, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("excel: reader is nil")
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to read CSV file: %w", err)
+	}
+	text, err := decodeCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(strings.NewReader(text))
+	csvReader.Comma = detectDelimiter(text)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to parse CSV file: %w", err)
+	}
+	return records, nil
+}
+
+// decodeCSV returns the file content as UTF-8. Files that are not valid
+// UTF-8 are taken to be Windows-1252, the default of Italian Windows.
+func decodeCSV(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("excel: failed to decode CSV file as Windows-1252: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// detectDelimiter picks the delimiter that splits the most lines into the
+// same number of fields, preferring the one giving more fields on ties.
+func detectDelimiter(text string) rune {
+	lines := strings.Split(text, "\n")
+	if len(lines) > csvSniffLines {
+		lines = lines[:csvSniffLines]
+	}
+
+	best, bestLines, bestCount := csvDelimiters[0], 0, 0
+	for _, delimiter := range csvDelimiters {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			if count := countOutsideQuotes(line, delimiter); count > 0 {
+				counts[count]++
+			}
+		}
+
+		for count, n := range counts {
+			if n > bestLines || (n == bestLines && count > bestCount) {
+				best, bestLines, bestCount = delimiter, n, count
+			}
+		}
+	}
+	return best
+}
+
+func countOutsideQuotes(line string, delimiter rune) int {
+	count := 0
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == delimiter && !quoted:
+			count++
+		}
+	}
+	return count
+}
+
+// textCells turns a row of untyped values into grid cells.
+func textCells(row int, values []string) []Cell {
+	cells := make([]Cell, len(values))
+	for c, value := range values {
+		cells[c] = Cell{Ref: cellRef(c+1, row), Row: row, Column: c + 1, Value: value, Type: CellTypeString}
+		if value == "" {
+			cells[c].Type = CellTypeEmpty
+		}
+	}
+	return cells
+}
@@ -0,0 +1,170 @@
+package excel
+
+import (
+	"bytes"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVService_ReadGridFromReader(t *testing.T) {
+	cs := NewCSVService()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    [][]string
+		expectedErr string
+	}{
+		{
+			name: "Comma separated",
+			data: []byte("Giornata 1,,,,\nTeamA,72.5,66,TeamB,2-1\n"),
+			expected: [][]string{
+				{"Giornata 1", "", "", "", ""},
+				{"TeamA", "72.5", "66", "TeamB", "2-1"},
+			},
+		},
+		{
+			name: "Semicolon separated with decimal commas",
+			data: []byte("1a Giornata;;;;;2a Giornata\r\nTeamA;72,5;66,5;TeamB;2-1;TeamA;60;61;TeamC;0-0\r\n"),
+			expected: [][]string{
+				{"1a Giornata", "", "", "", "", "2a Giornata"},
+				{"TeamA", "72,5", "66,5", "TeamB", "2-1", "TeamA", "60", "61", "TeamC", "0-0"},
+			},
+		},
+		{
+			name: "UTF-8 with BOM",
+			data: append([]byte{0xEF, 0xBB, 0xBF}, []byte("Città;70;66;Forlì;2-1\n")...),
+			expected: [][]string{
+				{"Città", "70", "66", "Forlì", "2-1"},
+			},
+		},
+		{
+			name: "Windows-1252",
+			data: []byte("Citt\xe0;70;66;Forl\xec;2-1\n"),
+			expected: [][]string{
+				{"Città", "70", "66", "Forlì", "2-1"},
+			},
+		},
+		{
+			name: "Quoted fields keep their delimiters",
+			data: []byte("\"Rossi, Bianchi & C.\",70,66,TeamB,2-1\n"),
+			expected: [][]string{
+				{"Rossi, Bianchi & C.", "70", "66", "TeamB", "2-1"},
+			},
+		},
+		{
+			name:        "Empty file",
+			data:        []byte(""),
+			expectedErr: "excel: file contains no valid data rows after filtering",
+		},
+		{
+			name:        "Only delimiters",
+			data:        []byte(";;;\n;;;\n"),
+			expectedErr: "excel: file contains no valid data rows after filtering",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, err := cs.ReadGridFromReader(bytes.NewReader(tt.data), Options{})
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, grid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, grid.Values())
+		})
+	}
+}
+
+func TestCSVService_GridCells(t *testing.T) {
+	cs := NewCSVService()
+
+	grid, err := cs.ReadGridFromReader(bytes.NewReader([]byte("TeamA;;66\n")), Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, Cell{Ref: "A1", Row: 1, Column: 1, Value: "TeamA", Type: CellTypeString}, grid.Rows[0][0])
+	assert.Equal(t, Cell{Ref: "B1", Row: 1, Column: 2, Value: "", Type: CellTypeEmpty}, grid.Rows[0][1])
+	assert.Equal(t, Cell{Ref: "C1", Row: 1, Column: 3, Value: "66", Type: CellTypeString}, grid.Rows[0][2])
+}
+
+func TestCSVService_ReadExcel(t *testing.T) {
+	cs := NewCSVService()
+
+	tests := []struct {
+		name        string
+		fileHeader  FileHeaderOpener
+		expected    [][]string
+		expectedErr string
+	}{
+		{
+			name: "Empty cells and rows are dropped",
+			fileHeader: &mockMultipartFile{
+				FileHeader: &multipart.FileHeader{Filename: "calendar.csv"},
+				mockOpenFunc: func() (multipart.File, error) {
+					return newMockFile(bytes.NewReader([]byte("Giornata 1;;;;\n;;;;\nTeamA;70;;TeamB;2-1\n"))), nil
+				},
+			},
+			expected: [][]string{
+				{"Giornata 1"},
+				{"TeamA", "70", "TeamB", "2-1"},
+			},
+		},
+		{
+			name:        "Nil file header",
+			fileHeader:  nil,
+			expectedErr: "file header is nil",
+		},
+		{
+			name: "Open() returns error",
+			fileHeader: &mockMultipartFile{
+				FileHeader: &multipart.FileHeader{Filename: "error.csv"},
+				mockOpenFunc: func() (multipart.File, error) {
+					return nil, assert.AnError
+				},
+			},
+			expectedErr: assert.AnError.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := cs.ReadExcel(tt.fileHeader)
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, rows)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rows)
+		})
+	}
+}
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want rune
+	}{
+		{name: "Comma", text: "a,b,c\nd,e,f\n", want: ','},
+		{name: "Semicolon", text: "a;b;c\nd;e;f\n", want: ';'},
+		{name: "Semicolon with decimal commas", text: "TeamA;72,5;66;TeamB;2-1\nTeamC;60,5;61,5;TeamD;0-0\n", want: ';'},
+		{name: "Tab", text: "a\tb\tc\n", want: '\t'},
+		{name: "Single column", text: "a\nb\n", want: ';'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectDelimiter(tt.text))
+		})
+	}
+}
@@ -2,9 +2,85 @@ package excel
 
 import (
 	"io"
+	"strconv"
+	"time"
 )
 
 type ExcelService interface {
 	ReadExcelFromReader(reader io.Reader) ([][]string, error)
 	ReadExcel(fileHeader FileHeaderOpener) ([][]string, error)
+	ReadGridFromReader(reader io.Reader, opts Options) (*Grid, error)
+	ReadGrid(fileHeader FileHeaderOpener, opts Options) (*Grid, error)
+}
+
+type CellType int
+
+const (
+	CellTypeEmpty CellType = iota
+	CellTypeString
+	CellTypeNumber
+	CellTypeBool
+	CellTypeDate
+	CellTypeError
+)
+
+// Cell is a sheet cell read in grid mode, with its 1-based coordinates and
+// its reference, e.g. "E14".
+type Cell struct {
+	Ref    string
+	Row    int
+	Column int
+	// Value is the text of the cell as the sheet displays it.
+	Value string
+	Type  CellType
+	// Number, Bool and Time hold the value of number, bool and date cells.
+	Number float64
+	Bool   bool
+	Time   time.Time
+	// Formula is set for computed cells, whose typed value is the one cached
+	// in the file.
+	Formula string
+}
+
+// Text returns the cell value with numbers written out in full, whatever
+// number format and locale the sheet displays them with.
+func (c Cell) Text() string {
+	if c.Type == CellTypeNumber {
+		return strconv.FormatFloat(c.Number, 'f', -1, 64)
+	}
+	return c.Value
+}
+
+// MergedRange is a range of merged cells such as "A1:E1". Its value is the
+// one of the top left cell.
+type MergedRange struct {
+	Start string
+	End   string
+	Value string
+}
+
+// maxGridCells bounds the cells laid out from a whole document, so that a
+// small file repeating or scattering cells over many rows and columns cannot
+// make the grid take up the memory of the server. Calendars are far smaller.
+const maxGridCells = 1000000
+
+// Grid is a sheet read keeping every cell where it is: Rows[i][j] is the cell
+// at row i+1, column j+1. Empty cells and rows are kept, only trailing empty
+// cells of a row are left out.
+type Grid struct {
+	Sheet  string
+	Rows   [][]Cell
+	Merged []MergedRange
+}
+
+// Values returns the text of every cell, keeping their positions.
+func (g *Grid) Values() [][]string {
+	values := make([][]string, len(g.Rows))
+	for i, row := range g.Rows {
+		values[i] = make([]string, len(row))
+		for j, cell := range row {
+			values[i][j] = cell.Text()
+		}
+	}
+	return values
 }
@@ -4,10 +4,26 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
+// dateNumFmts are the built-in number formats showing a date or a time.
+var dateNumFmts = map[int]bool{
+	14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true,
+	27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	45: true, 46: true, 47: true,
+	50: true, 51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true,
+}
+
+// numFmtLiterals matches the quoted text, escaped characters and bracketed
+// sections (colors, locales) of a number format, which never make it a date.
+var numFmtLiterals = regexp.MustCompile(`"[^"]*"|\\.|\[[^\]]*\]`)
+
 type ExcelServiceImpl struct{}
 
 func NewExcelService() *ExcelServiceImpl {
@@ -18,21 +34,17 @@ type FileHeaderOpener interface {
 	Open() (multipart.File, error) // <-- THIS IS THE CRITICAL SIGNATURE
 }
 
+// ReadExcelFromReader reads the calendar sheet, dropping empty rows and cells.
 func (es *ExcelServiceImpl) ReadExcelFromReader(reader io.Reader) ([][]string, error) {
 	if reader == nil {
 		return nil, fmt.Errorf("excel: reader is nil")
 	}
 
-	f, err := excelize.OpenReader(reader)
+	f, sheetName, err := openSheet(reader, Options{})
 	if err != nil {
-		return nil, fmt.Errorf("excel: failed to open Excel file with excelize: %w", err)
-	}
-
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("excel: no sheets found in the Excel file")
+		return nil, err
 	}
-	sheetName := sheets[0]
+	defer f.Close()
 
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
@@ -69,6 +81,159 @@ func (es *ExcelServiceImpl) ReadExcelFromReader(reader io.Reader) ([][]string, e
 	return filteredRows, nil
 }
 
+// ReadGridFromReader reads the selected sheet in grid mode, keeping empty
+// cells so that every value stays at its column, and types every cell from
+// the information stored in the file.
+func (es *ExcelServiceImpl) ReadGridFromReader(reader io.Reader, opts Options) (*Grid, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("excel: reader is nil")
+	}
+
+	f, sheetName, err := openSheet(reader, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to get rows from sheet '%s': %w", sheetName, err)
+	}
+	rawRows, err := f.GetRows(sheetName, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to get raw values from sheet '%s': %w", sheetName, err)
+	}
+
+	props, err := f.GetWorkbookProps()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to get workbook properties: %w", err)
+	}
+	date1904 := props.Date1904 != nil && *props.Date1904
+
+	grid := &Grid{Sheet: sheetName}
+	hasData := false
+	for r, row := range rows {
+		cells := make([]Cell, len(row))
+		for c, value := range row {
+			raw := value
+			if r < len(rawRows) && c < len(rawRows[r]) {
+				raw = rawRows[r][c]
+			}
+			cell, err := readCell(f, sheetName, r+1, c+1, value, raw, date1904)
+			if err != nil {
+				return nil, err
+			}
+			cells[c] = cell
+			if value != "" {
+				hasData = true
+			}
+		}
+		grid.Rows = append(grid.Rows, cells)
+	}
+
+	if !hasData {
+		return nil, fmt.Errorf("excel: file contains no valid data rows after filtering")
+	}
+
+	mergeCells, err := f.GetMergeCells(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to get merged cells from sheet '%s': %w", sheetName, err)
+	}
+	for _, mergeCell := range mergeCells {
+		grid.Merged = append(grid.Merged, MergedRange{
+			Start: mergeCell.GetStartAxis(),
+			End:   mergeCell.GetEndAxis(),
+			Value: mergeCell.GetCellValue(),
+		})
+	}
+
+	return grid, nil
+}
+
+// readCell types a cell from its type in the file and, for numbers, from its
+// number format, which is all that tells dates apart.
+func readCell(f *excelize.File, sheet string, row, column int, value, raw string, date1904 bool) (Cell, error) {
+	ref, err := excelize.CoordinatesToCellName(column, row)
+	if err != nil {
+		return Cell{}, fmt.Errorf("excel: invalid cell at row %d, column %d: %w", row, column, err)
+	}
+	cell := Cell{Ref: ref, Row: row, Column: column, Value: value}
+
+	if cell.Formula, err = f.GetCellFormula(sheet, ref); err != nil {
+		return Cell{}, fmt.Errorf("excel: failed to get formula of cell %s: %w", ref, err)
+	}
+	cellType, err := f.GetCellType(sheet, ref)
+	if err != nil {
+		return Cell{}, fmt.Errorf("excel: failed to get type of cell %s: %w", ref, err)
+	}
+
+	switch {
+	case raw == "" && value == "":
+		cell.Type = CellTypeEmpty
+	case cellType == excelize.CellTypeBool:
+		cell.Type = CellTypeBool
+		cell.Bool = raw == "1" || strings.EqualFold(raw, "true")
+	case cellType == excelize.CellTypeError:
+		cell.Type = CellTypeError
+	case cellType == excelize.CellTypeDate:
+		cell.Type = CellTypeString
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			cell.Type, cell.Time = CellTypeDate, t
+		}
+	case cellType == excelize.CellTypeUnset || cellType == excelize.CellTypeNumber:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			cell.Type = CellTypeString
+			break
+		}
+		isDate, err := hasDateFormat(f, sheet, ref)
+		if err != nil {
+			return Cell{}, err
+		}
+		if isDate {
+			if t, err := excelize.ExcelDateToTime(number, date1904); err == nil {
+				cell.Type, cell.Time = CellTypeDate, t
+				break
+			}
+		}
+		cell.Type, cell.Number = CellTypeNumber, number
+	default:
+		cell.Type = CellTypeString
+	}
+
+	return cell, nil
+}
+
+// cellRef returns the reference of a cell, e.g. "E14" for column 5, row 14.
+func cellRef(column, row int) string {
+	ref, _ := excelize.CoordinatesToCellName(column, row)
+	return ref
+}
+
+// hasDateFormat tells whether the number format of a cell shows a date.
+func hasDateFormat(f *excelize.File, sheet, ref string) (bool, error) {
+	styleID, err := f.GetCellStyle(sheet, ref)
+	if err != nil {
+		return false, fmt.Errorf("excel: failed to get style of cell %s: %w", ref, err)
+	}
+	style, err := f.GetStyle(styleID)
+	if err != nil {
+		return false, fmt.Errorf("excel: failed to get style of cell %s: %w", ref, err)
+	}
+
+	if style.CustomNumFmt == nil {
+		return dateNumFmts[style.NumFmt], nil
+	}
+	return isDateFormatCode(*style.CustomNumFmt), nil
+}
+
+// isDateFormatCode tells whether a custom number format code shows a date or
+// a time.
+func isDateFormatCode(code string) bool {
+	format := strings.ToLower(numFmtLiterals.ReplaceAllString(code, ""))
+	return strings.ContainsAny(format, "dyh")
+}
+
 func (es *ExcelServiceImpl) ReadExcel(fileHeader FileHeaderOpener) ([][]string, error) {
 	if fileHeader == nil {
 		return nil, fmt.Errorf("file header is nil")
@@ -82,3 +247,34 @@ func (es *ExcelServiceImpl) ReadExcel(fileHeader FileHeaderOpener) ([][]string,
 
 	return es.ReadExcelFromReader(file)
 }
+
+func (es *ExcelServiceImpl) ReadGrid(fileHeader FileHeaderOpener, opts Options) (*Grid, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return es.ReadGridFromReader(file, opts)
+}
+
+// openSheet opens the workbook and returns the name of the sheet to read.
+func openSheet(reader io.Reader, opts Options) (*excelize.File, string, error) {
+	f, err := excelize.OpenReader(reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("excel: failed to open Excel file with excelize: %w", err)
+	}
+
+	sheetName, err := selectSheet(f.GetSheetList(), opts.Sheet, func(sheet string) ([][]string, error) {
+		return f.GetRows(sheet)
+	})
+	if err != nil {
+		f.Close()
+		return nil, "", err
+	}
+	return f, sheetName, nil
+}
@@ -1,11 +1,13 @@
 package excel
 
 import (
+	"archive/zip"
 	"bytes"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -243,3 +245,199 @@ func TestExcelService_ReadExcel(t *testing.T) {
 		})
 	}
 }
+
+func TestExcelService_ReadGridFromReader(t *testing.T) {
+	es := ExcelServiceImpl{}
+
+	f := excelize.NewFile()
+	require.NoError(t, f.SetCellValue("Sheet1", "A1", "1a Giornata lega"))
+	require.NoError(t, f.MergeCell("Sheet1", "A1", "E1"))
+	require.NoError(t, f.SetCellValue("Sheet1", "A3", "TeamA"))
+	require.NoError(t, f.SetCellValue("Sheet1", "C3", "66"))
+	require.NoError(t, f.SetCellValue("Sheet1", "D3", "TeamB"))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	grid, err := es.ReadGridFromReader(bytes.NewReader(buf.Bytes()), Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Sheet1", grid.Sheet)
+	assert.Equal(t, [][]string{
+		{"1a Giornata lega"},
+		nil,
+		{"TeamA", "", "66", "TeamB"},
+	}, normalizeEmptyRows(grid.Values()))
+	assert.Equal(t, Cell{Ref: "D3", Row: 3, Column: 4, Value: "TeamB", Type: CellTypeString}, grid.Rows[2][3])
+	assert.Equal(t, Cell{Ref: "B3", Row: 3, Column: 2, Value: "", Type: CellTypeEmpty}, grid.Rows[2][1])
+	assert.Equal(t, []MergedRange{{Start: "A1", End: "E1", Value: "1a Giornata lega"}}, grid.Merged)
+}
+
+func TestExcelService_ReadGrid(t *testing.T) {
+	es := ExcelServiceImpl{}
+
+	tests := []struct {
+		name        string
+		fileHeader  FileHeaderOpener
+		expected    [][]string
+		expectedErr string
+	}{
+		{
+			name: "Valid multipart file",
+			fileHeader: func() FileHeaderOpener {
+				f := excelize.NewFile()
+				f.SetCellValue("Sheet1", "A1", "Test")
+				f.SetCellValue("Sheet1", "C1", "Data")
+				var buf bytes.Buffer
+				require.NoError(t, f.Write(&buf), "Failed to write dummy Excel file")
+
+				return &mockMultipartFile{
+					FileHeader: &multipart.FileHeader{Filename: "valid.xlsx", Size: int64(buf.Len())},
+					mockOpenFunc: func() (multipart.File, error) {
+						return newMockFile(&buf), nil
+					},
+				}
+			}(),
+			expected: [][]string{{"Test", "", "Data"}},
+		},
+		{
+			name:        "Nil file header",
+			fileHeader:  nil,
+			expectedErr: "file header is nil",
+		},
+		{
+			name: "Empty sheet",
+			fileHeader: func() FileHeaderOpener {
+				f := excelize.NewFile()
+				var buf bytes.Buffer
+				require.NoError(t, f.Write(&buf), "Failed to write dummy Excel file")
+
+				return &mockMultipartFile{
+					FileHeader: &multipart.FileHeader{Filename: "empty.xlsx", Size: int64(buf.Len())},
+					mockOpenFunc: func() (multipart.File, error) {
+						return newMockFile(&buf), nil
+					},
+				}
+			}(),
+			expectedErr: "excel: file contains no valid data rows after filtering",
+		},
+		{
+			name: "Open() returns malformed data",
+			fileHeader: &mockMultipartFile{
+				FileHeader: &multipart.FileHeader{Filename: "malformed_stream.xlsx", Size: 100},
+				mockOpenFunc: func() (multipart.File, error) {
+					return newMockFile(bytes.NewReader([]byte("malformed stream data"))), nil
+				},
+			},
+			expectedErr: "failed to open Excel file with excelize",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, actualErr := es.ReadGrid(tt.fileHeader, Options{})
+
+			if tt.expectedErr != "" {
+				assert.Error(t, actualErr, "Expected error for test '%s'", tt.name)
+				assert.Contains(t, actualErr.Error(), tt.expectedErr, "Error message mismatch for test '%s'", tt.name)
+				assert.Nil(t, grid, "Expected nil grid on error for test '%s'", tt.name)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect error for test '%s': %v", tt.name, actualErr)
+				assert.Equal(t, tt.expected, grid.Values(), "Data mismatch for test '%s'", tt.name)
+			}
+		})
+	}
+}
+
+// normalizeEmptyRows turns empty rows into nil so they compare equal however
+// they were built.
+func normalizeEmptyRows(rows [][]string) [][]string {
+	for i, row := range rows {
+		if len(row) == 0 {
+			rows[i] = nil
+		}
+	}
+	return rows
+}
+
+func TestExcelService_ReadGridTypedCells(t *testing.T) {
+	es := ExcelServiceImpl{}
+
+	f := excelize.NewFile()
+	twoDecimals, err := f.NewStyle(&excelize.Style{NumFmt: 2})
+	require.NoError(t, err)
+	italianDecimals := "#,##0.0"
+	customStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &italianDecimals})
+	require.NoError(t, err)
+	customDate := "dd/mm/yyyy"
+	customDateStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &customDate})
+	require.NoError(t, err)
+
+	require.NoError(t, f.SetCellValue("Sheet1", "A1", "TeamA"))
+	require.NoError(t, f.SetCellValue("Sheet1", "B1", 66.5))
+	require.NoError(t, f.SetCellStyle("Sheet1", "B1", "B1", twoDecimals))
+	require.NoError(t, f.SetCellValue("Sheet1", "C1", 72.5))
+	require.NoError(t, f.SetCellStyle("Sheet1", "C1", "C1", customStyle))
+	require.NoError(t, f.SetCellValue("Sheet1", "D1", "1-0"))
+	require.NoError(t, f.SetCellValue("Sheet1", "E1", true))
+	require.NoError(t, f.SetCellValue("Sheet1", "F1", 45292))
+	require.NoError(t, f.SetCellStyle("Sheet1", "F1", "F1", customDateStyle))
+	require.NoError(t, f.SetCellFormula("Sheet1", "G1", "B1*2"))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	// excelize marks formula results as strings, Excel leaves numbers untyped.
+	data := replaceInWorkbook(t, buf.Bytes(), "xl/worksheets/sheet1.xml", `t="str"><f>B1*2</f>`, `><f>B1*2</f><v>133</v>`)
+
+	grid, err := es.ReadGridFromReader(bytes.NewReader(data), Options{})
+	require.NoError(t, err)
+	row := grid.Rows[0]
+
+	assert.Equal(t, CellTypeString, row[0].Type)
+	assert.Equal(t, CellTypeNumber, row[1].Type)
+	assert.Equal(t, 66.5, row[1].Number)
+	assert.Equal(t, "66.50", row[1].Value)
+	assert.Equal(t, CellTypeNumber, row[2].Type)
+	assert.Equal(t, 72.5, row[2].Number)
+	assert.Equal(t, CellTypeString, row[3].Type)
+	assert.Equal(t, "1-0", row[3].Value)
+	assert.Equal(t, CellTypeBool, row[4].Type)
+	assert.True(t, row[4].Bool)
+	assert.Equal(t, CellTypeDate, row[5].Type)
+	assert.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), row[5].Time)
+	assert.Equal(t, CellTypeNumber, row[6].Type)
+	assert.Equal(t, "B1*2", row[6].Formula)
+	assert.Equal(t, 133.0, row[6].Number)
+
+	assert.Equal(t, []string{"TeamA", "66.5", "72.5", "1-0", "TRUE", row[5].Value, "133"}, grid.Values()[0])
+}
+
+// replaceInWorkbook edits a part of a workbook, e.g. to store the cached value
+// of a formula as Excel does when it saves a file; excelize only writes the
+// formula.
+func replaceInWorkbook(t *testing.T, workbook []byte, partPath, old, new string) []byte {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	writer := zip.NewWriter(&out)
+	for _, file := range reader.File {
+		content, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		content.Close()
+
+		if file.Name == partPath {
+			data = bytes.Replace(data, []byte(old), []byte(new), 1)
+		}
+		entry, err := writer.Create(file.Name)
+		require.NoError(t, err)
+		_, err = entry.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return out.Bytes()
+}
@@ -0,0 +1,111 @@
+package excel
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a calendar file format, each read by its own ExcelService except
+// HTML pages, which the parser reads directly.
+type Format string
+
+const (
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+	FormatODS  Format = "ods"
+	FormatXLS  Format = "xls"
+	FormatHTML Format = "html"
+)
+
+// sniffLength is how much of an upload is read to recognise its signature.
+const sniffLength = 512
+
+var (
+	zipSignature = []byte("PK\x03\x04")
+	// Legacy .xls workbooks are OLE compound files.
+	oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	// An OpenDocument package stores its media type, uncompressed, as the
+	// first entry of the archive.
+	odsMimetype = []byte("mimetypeapplication/vnd.oasis.opendocument.spreadsheet")
+)
+
+var extensionFormats = map[string]Format{
+	".xlsx": FormatXLSX,
+	".xlsm": FormatXLSX,
+	".csv":  FormatCSV,
+	".ods":  FormatODS,
+	".xls":  FormatXLS,
+	".html": FormatHTML,
+	".htm":  FormatHTML,
+}
+
+var contentTypeFormats = map[string]Format{
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FormatXLSX,
+	"text/csv":                    FormatCSV,
+	"application/csv":             FormatCSV,
+	"text/comma-separated-values": FormatCSV,
+	"application/vnd.oasis.opendocument.spreadsheet": FormatODS,
+	// Browsers send CSV files as Excel ones; legacy workbooks are told
+	// apart by their OLE signature instead, see SniffFormat.
+	"application/vnd.ms-excel": FormatCSV,
+	"text/html":                FormatHTML,
+}
+
+// DetectFormat tells the format of an upload from its file name and, when the
+// extension is unknown, its content type. Browsers often send CSV files as
+// "application/vnd.ms-excel", so the extension is trusted first and that
+// content type is taken as CSV. Anything unrecognised is assumed to be an
+// Excel workbook.
+func DetectFormat(filename, contentType string) Format {
+	if format, ok := extensionFormats[strings.ToLower(filepath.Ext(filename))]; ok {
+		return format
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if format, ok := contentTypeFormats[strings.ToLower(mediaType)]; ok {
+			return format
+		}
+	}
+
+	return FormatXLSX
+}
+
+// SniffFormat recognises a format from the first bytes of a file. Formats
+// without a signature, such as CSV, are not recognised.
+func SniffFormat(header []byte) (Format, bool) {
+	if bytes.HasPrefix(header, oleSignature) {
+		return FormatXLS, true
+	}
+	if strings.HasPrefix(http.DetectContentType(bytes.TrimPrefix(header, utf8BOM)), "text/html") {
+		return FormatHTML, true
+	}
+	if !bytes.HasPrefix(header, zipSignature) {
+		return "", false
+	}
+	if bytes.Contains(header, odsMimetype) {
+		return FormatODS, true
+	}
+	return FormatXLSX, true
+}
+
+// DetectUploadFormat tells the format of an uploaded file from its content,
+// whatever it is named, and falls back on DetectFormat when the content has
+// no known signature or cannot be read.
+func DetectUploadFormat(fileHeader *multipart.FileHeader) Format {
+	if file, err := fileHeader.Open(); err == nil {
+		header := make([]byte, sniffLength)
+		n, _ := io.ReadFull(file, header)
+		file.Close()
+		if format, ok := SniffFormat(header[:n]); ok {
+			return format
+		}
+	}
+
+	return DetectFormat(fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+}
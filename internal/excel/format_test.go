@@ -0,0 +1,100 @@
+package excel
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		want        Format
+	}{
+		{name: "Excel extension", filename: "calendario.xlsx", want: FormatXLSX},
+		{name: "CSV extension", filename: "Calendario.CSV", want: FormatCSV},
+		{name: "CSV sent as Excel by the browser", filename: "calendario.csv", contentType: "application/vnd.ms-excel", want: FormatCSV},
+		{name: "ODS extension", filename: "calendario.ods", want: FormatODS},
+		{name: "Legacy Excel extension", filename: "stagione2012.XLS", want: FormatXLS},
+		{name: "Saved page", filename: "Calendario - Lega degli Amici.htm", want: FormatHTML},
+		{name: "CSV content type", filename: "calendario", contentType: "text/csv; charset=utf-8", want: FormatCSV},
+		{name: "Legacy Excel content type without extension", filename: "calendario", contentType: "application/vnd.ms-excel", want: FormatCSV},
+		{name: "Excel content type", filename: "upload", contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", want: FormatXLSX},
+		{name: "Unknown", filename: "upload.bin", contentType: "application/octet-stream", want: FormatXLSX},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectFormat(tt.filename, tt.contentType))
+		})
+	}
+}
+
+// uploadedFile returns the file header of a file uploaded as a form field.
+func uploadedFile(t *testing.T, filename, contentType string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestDetectUploadFormat(t *testing.T) {
+	f := excelize.NewFile()
+	require.NoError(t, f.SetCellValue("Sheet1", "A1", "Giornata 1"))
+	xlsx, err := f.WriteToBuffer()
+	require.NoError(t, err)
+	ods := createODS(t, odsCalendar)
+	xls := xlsCalendar(t)
+
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		content     []byte
+		want        Format
+	}{
+		{name: "ODS named as Excel", filename: "calendario.xlsx", contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", content: ods, want: FormatODS},
+		{name: "ODS without extension", filename: "calendario", contentType: "application/octet-stream", content: ods, want: FormatODS},
+		{name: "Excel named as ODS", filename: "calendario.ods", contentType: "application/vnd.oasis.opendocument.spreadsheet", content: xlsx.Bytes(), want: FormatXLSX},
+		{name: "Excel named as CSV", filename: "calendario.csv", contentType: "text/csv", content: xlsx.Bytes(), want: FormatXLSX},
+		{name: "Legacy Excel named as Excel", filename: "stagione2012.xlsx", contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", content: xls, want: FormatXLS},
+		{name: "Saved page without extension", filename: "calendario", contentType: "application/octet-stream", content: []byte("\xEF\xBB\xBF\n<!DOCTYPE html><html><body><table></table></body></html>"), want: FormatHTML},
+		{name: "CSV falls back on the name", filename: "calendario.csv", contentType: "application/vnd.ms-excel", content: []byte("Giornata 1;;;;\n"), want: FormatCSV},
+		{name: "CSV sent as Excel without extension", filename: "calendario", contentType: "application/vnd.ms-excel", content: []byte("Giornata 1;;;;\n"), want: FormatCSV},
+		{name: "Legacy Excel without extension", filename: "calendario", contentType: "application/vnd.ms-excel", content: xls, want: FormatXLS},
+		{name: "Empty file falls back on the name", filename: "calendario.ods", contentType: "", content: nil, want: FormatODS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileHeader := uploadedFile(t, tt.filename, tt.contentType, tt.content)
+			assert.Equal(t, tt.want, DetectUploadFormat(fileHeader))
+		})
+	}
+}
+
+func TestDetectUploadFormat_UnreadableFile(t *testing.T) {
+	// A header without content cannot be opened, the name is all there is.
+	fileHeader := &multipart.FileHeader{Filename: "calendario.csv"}
+	assert.Equal(t, FormatCSV, DetectUploadFormat(fileHeader))
+}
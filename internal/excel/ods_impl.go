@@ -0,0 +1,457 @@
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenDocument namespaces of the elements and attributes read from
+// content.xml.
+const (
+	odsOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// Spreadsheet applications pad sheets with a single row or cell repeated up
+// to the sheet size. Repeated empty cells and rows are only written out when
+// data follows them, and the sheet is bounded like an Excel one.
+const (
+	odsMaxRows    = 1048576
+	odsMaxColumns = 16384
+)
+
+// odsMaxText bounds the text of a single cell, as Excel does.
+const odsMaxText = 32767
+
+// ODSServiceImpl reads calendars kept as OpenDocument spreadsheets (.ods), as
+// saved by LibreOffice and OpenOffice.
+type ODSServiceImpl struct{}
+
+func NewODSService() *ODSServiceImpl {
+	return &ODSServiceImpl{}
+}
+
+// odsSheet is a sheet of the document, already laid out as a grid.
+type odsSheet struct {
+	name string
+	grid *Grid
+}
+
+// odsCell is a cell as written in content.xml, before its repetitions are
+// laid out.
+type odsCell struct {
+	cell        Cell
+	repeat      int
+	columnsSpan int
+	rowsSpan    int
+}
+
+func (ods *ODSServiceImpl) ReadExcelFromReader(reader io.Reader) ([][]string, error) {
+	grid, err := ods.ReadGridFromReader(reader, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredRows [][]string
+	for _, row := range grid.Rows {
+		var newRow []string
+		for _, cell := range row {
+			if cell.Value != "" {
+				newRow = append(newRow, cell.Value)
+			}
+		}
+		if len(newRow) > 0 {
+			filteredRows = append(filteredRows, newRow)
+		}
+	}
+
+	return filteredRows, nil
+}
+
+func (ods *ODSServiceImpl) ReadExcel(fileHeader FileHeaderOpener) ([][]string, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return ods.ReadExcelFromReader(file)
+}
+
+// ReadGridFromReader reads the selected sheet in grid mode. Cells are typed
+// from their office:value-type, their Value is the text the sheet displays.
+func (ods *ODSServiceImpl) ReadGridFromReader(reader io.Reader, opts Options) (*Grid, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("excel: reader is nil")
+	}
+
+	sheets, err := readODS(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(sheets))
+	grids := make(map[string]*Grid, len(sheets))
+	for i, sheet := range sheets {
+		names[i] = sheet.name
+		grids[sheet.name] = sheet.grid
+	}
+
+	sheetName, err := selectSheet(names, opts.Sheet, func(sheet string) ([][]string, error) {
+		return grids[sheet].Values(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grid := grids[sheetName]
+	for _, row := range grid.Rows {
+		for _, cell := range row {
+			if cell.Value != "" {
+				return grid, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("excel: file contains no valid data rows after filtering")
+}
+
+func (ods *ODSServiceImpl) ReadGrid(fileHeader FileHeaderOpener, opts Options) (*Grid, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return ods.ReadGridFromReader(file, opts)
+}
+
+// readODS reads every sheet of the document from its content.xml.
+func readODS(reader io.Reader) ([]odsSheet, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to read OpenDocument file: %w", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open OpenDocument file: %w", err)
+	}
+
+	content, err := archive.Open("content.xml")
+	if err != nil {
+		return nil, fmt.Errorf("excel: OpenDocument file has no content.xml: %w", err)
+	}
+	defer content.Close()
+
+	sheets, err := decodeODSContent(xml.NewDecoder(content))
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to read OpenDocument content: %w", err)
+	}
+	return sheets, nil
+}
+
+// decodeODSContent walks content.xml collecting the tables. Row groups and
+// header rows are flattened, only the rows themselves matter.
+func decodeODSContent(decoder *xml.Decoder) ([]odsSheet, error) {
+	var sheets []odsSheet
+	var current *odsSheet
+	pendingRows := 0
+	laidOut := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return sheets, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case start.Name.Space == odsTableNS && start.Name.Local == "table":
+			sheets = append(sheets, odsSheet{name: odsAttr(start, odsTableNS, "name"), grid: &Grid{}})
+			current = &sheets[len(sheets)-1]
+			current.grid.Sheet = current.name
+			pendingRows = 0
+		case start.Name.Space == odsTableNS && start.Name.Local == "table-row" && current != nil:
+			cells, err := decodeODSRow(decoder)
+			if err != nil {
+				return nil, err
+			}
+			repeat := odsCount(start, odsTableNS, "number-rows-repeated", odsMaxRows)
+			if len(cells) == 0 {
+				// Trailing padding may run past the sheet, only data
+				// following it must not.
+				pendingRows = min(pendingRows+repeat, odsMaxRows+1)
+				continue
+			}
+
+			if repeat > odsMaxRows-len(current.grid.Rows)-pendingRows {
+				return nil, fmt.Errorf("sheet '%s' has more than %d rows", current.name, odsMaxRows)
+			}
+			width := 0
+			for _, cell := range cells {
+				width += cell.repeat
+			}
+			if width*repeat > maxGridCells-laidOut {
+				return nil, fmt.Errorf("document has more than %d cells", maxGridCells)
+			}
+			laidOut += width * repeat
+			for ; pendingRows > 0; pendingRows-- {
+				current.grid.Rows = append(current.grid.Rows, nil)
+			}
+			for i := 0; i < repeat; i++ {
+				layOutODSRow(current.grid, cells)
+			}
+		}
+	}
+}
+
+// decodeODSRow reads the cells of a row up to its end element. Empty cells at
+// the end of the row are dropped.
+func decodeODSRow(decoder *xml.Decoder) ([]odsCell, error) {
+	var cells []odsCell
+	pendingEmpty := 0
+	columns := 0
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			if t.Name.Space == odsTableNS && t.Name.Local == "table-row" {
+				return cells, nil
+			}
+		case xml.StartElement:
+			if t.Name.Space != odsTableNS || (t.Name.Local != "table-cell" && t.Name.Local != "covered-table-cell") {
+				continue
+			}
+			cell, err := decodeODSCell(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+
+			// Trailing empty cells may run past the sheet, only data
+			// following them must not.
+			past := cell.repeat > odsMaxColumns-columns
+			columns = min(columns+cell.repeat, odsMaxColumns+1)
+			if cell.cell.Type == CellTypeEmpty && cell.columnsSpan <= 1 && cell.rowsSpan <= 1 {
+				pendingEmpty = min(pendingEmpty+cell.repeat, odsMaxColumns)
+				continue
+			}
+			if past {
+				return nil, fmt.Errorf("row has more than %d columns", odsMaxColumns)
+			}
+			if pendingEmpty > 0 {
+				cells = append(cells, odsCell{cell: Cell{Type: CellTypeEmpty}, repeat: pendingEmpty})
+				pendingEmpty = 0
+			}
+			cells = append(cells, cell)
+		}
+	}
+}
+
+// decodeODSCell reads a cell, typed from its attributes, with the text of its
+// paragraphs as the displayed value.
+func decodeODSCell(decoder *xml.Decoder, start xml.StartElement) (odsCell, error) {
+	text, err := decodeODSText(decoder, start.Name)
+	if err != nil {
+		return odsCell{}, err
+	}
+
+	cell := odsCell{
+		cell:        Cell{Value: text},
+		repeat:      odsCount(start, odsTableNS, "number-columns-repeated", odsMaxColumns),
+		columnsSpan: odsCount(start, odsTableNS, "number-columns-spanned", odsMaxColumns),
+		rowsSpan:    odsCount(start, odsTableNS, "number-rows-spanned", odsMaxRows),
+	}
+	if formula := odsAttr(start, odsTableNS, "formula"); formula != "" {
+		formula = strings.TrimPrefix(formula, "of:")
+		cell.cell.Formula = strings.TrimPrefix(formula, "=")
+	}
+
+	switch odsAttr(start, odsOfficeNS, "value-type") {
+	case "float", "percentage", "currency":
+		number, err := strconv.ParseFloat(odsAttr(start, odsOfficeNS, "value"), 64)
+		if err != nil {
+			cell.cell.Type = CellTypeString
+			break
+		}
+		cell.cell.Type, cell.cell.Number = CellTypeNumber, number
+		if cell.cell.Value == "" {
+			cell.cell.Value = odsAttr(start, odsOfficeNS, "value")
+		}
+	case "date":
+		cell.cell.Type = CellTypeString
+		if t, ok := parseODSDate(odsAttr(start, odsOfficeNS, "date-value")); ok {
+			cell.cell.Type, cell.cell.Time = CellTypeDate, t
+		}
+	case "boolean":
+		cell.cell.Type = CellTypeBool
+		cell.cell.Bool = odsAttr(start, odsOfficeNS, "boolean-value") == "true"
+	default:
+		if cell.cell.Value != "" {
+			cell.cell.Type = CellTypeString
+		}
+	}
+
+	if cell.cell.Value == "" && cell.cell.Type != CellTypeNumber {
+		cell.cell.Type = CellTypeEmpty
+	}
+	return cell, nil
+}
+
+// decodeODSText collects the paragraphs of a cell up to its end element, one
+// per line. Comments attached to the cell are left out. Cells with more than
+// odsMaxText characters are an error.
+func decodeODSText(decoder *xml.Decoder, end xml.Name) (string, error) {
+	var paragraphs []string
+	var text strings.Builder
+	inParagraph := false
+	length := 0
+	grow := func(n int) error {
+		if n > odsMaxText-length {
+			return fmt.Errorf("cell text is longer than %d characters", odsMaxText)
+		}
+		length += n
+		return nil
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsOfficeNS && t.Name.Local == "annotation":
+				if err := decoder.Skip(); err != nil {
+					return "", err
+				}
+			case t.Name.Space == odsTextNS && t.Name.Local == "p":
+				inParagraph = true
+				text.Reset()
+			case t.Name.Space == odsTextNS && t.Name.Local == "s":
+				spaces := odsCount(t, odsTextNS, "c", odsMaxText+1)
+				if err := grow(spaces); err != nil {
+					return "", err
+				}
+				text.WriteString(strings.Repeat(" ", spaces))
+			case t.Name.Space == odsTextNS && t.Name.Local == "tab":
+				if err := grow(1); err != nil {
+					return "", err
+				}
+				text.WriteString("\t")
+			case t.Name.Space == odsTextNS && t.Name.Local == "line-break":
+				if err := grow(1); err != nil {
+					return "", err
+				}
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inParagraph {
+				if err := grow(len(t)); err != nil {
+					return "", err
+				}
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Space == odsTextNS && t.Name.Local == "p" {
+				if len(paragraphs) > 0 {
+					// The line joining the paragraphs.
+					if err := grow(1); err != nil {
+						return "", err
+					}
+				}
+				paragraphs = append(paragraphs, text.String())
+				inParagraph = false
+			}
+			if t.Name == end {
+				return strings.Join(paragraphs, "\n"), nil
+			}
+		}
+	}
+}
+
+// layOutODSRow appends a row to the grid, giving every cell its position and
+// recording the cells spanning several columns or rows as merged ranges.
+func layOutODSRow(grid *Grid, cells []odsCell) {
+	row := len(grid.Rows) + 1
+	var laidOut []Cell
+
+	for _, cell := range cells {
+		for i := 0; i < cell.repeat; i++ {
+			column := len(laidOut) + 1
+			c := cell.cell
+			c.Ref, c.Row, c.Column = cellRef(column, row), row, column
+			laidOut = append(laidOut, c)
+
+			if cell.columnsSpan > 1 || cell.rowsSpan > 1 {
+				grid.Merged = append(grid.Merged, MergedRange{
+					Start: c.Ref,
+					End:   cellRef(column+cell.columnsSpan-1, row+cell.rowsSpan-1),
+					Value: c.Value,
+				})
+			}
+		}
+	}
+
+	grid.Rows = append(grid.Rows, laidOut)
+}
+
+func odsAttr(start xml.StartElement, space, local string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == space && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// odsCount returns a repetition or span count of the element, 1 when unset
+// and at most limit.
+func odsCount(start xml.StartElement, space, local string, limit int) int {
+	count, err := strconv.Atoi(odsAttr(start, space, local))
+	if errors.Is(err, strconv.ErrRange) && count > 0 {
+		return limit
+	}
+	if err != nil || count < 1 {
+		return 1
+	}
+	return min(count, limit)
+}
+
+// parseODSDate parses an office:date-value, a date with an optional time.
+func parseODSDate(value string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
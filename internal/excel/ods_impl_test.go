@@ -0,0 +1,265 @@
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createODS builds an OpenDocument spreadsheet whose body holds the given
+// tables, stored the way LibreOffice does with the mimetype first.
+func createODS(t *testing.T, tables string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	require.NoError(t, err)
+	_, err = mimetype.Write([]byte("application/vnd.oasis.opendocument.spreadsheet"))
+	require.NoError(t, err)
+
+	content, err := zw.Create("content.xml")
+	require.NoError(t, err)
+	_, err = content.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content
+	xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	office:version="1.3">
+<office:body><office:spreadsheet>` + tables + `</office:spreadsheet></office:body>
+</office:document-content>`))
+	require.NoError(t, err)
+
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+const odsCalendar = `<table:table table:name="Calendario">
+<table:table-column table:number-columns-repeated="1024"/>
+<table:table-row>
+	<table:table-cell table:number-columns-spanned="5" office:value-type="string"><text:p>1ª Giornata</text:p></table:table-cell>
+	<table:covered-table-cell table:number-columns-repeated="4"/>
+	<table:table-cell table:number-columns-repeated="1019"/>
+</table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+<table:table-row>
+	<table:table-cell office:value-type="string"><text:p>Real<text:s/>Madrink</text:p><office:annotation><dc:creator>Anna</dc:creator><text:p>Ricontrollare</text:p></office:annotation></table:table-cell>
+	<table:table-cell office:value-type="float" office:value="72.5"><text:p>72,5</text:p></table:table-cell>
+	<table:table-cell office:value-type="float" office:value="66" table:formula="of:=[.B4]-6.5"><text:p>66</text:p></table:table-cell>
+	<table:table-cell office:value-type="string"><text:p><text:span>Atletico</text:span> Ma<text:s text:c="2"/>Non Troppo</text:p></table:table-cell>
+	<table:table-cell office:value-type="string"><text:p>2-1</text:p></table:table-cell>
+	<table:table-cell table:number-columns-repeated="1019"/>
+</table:table-row>
+<table:table-row table:number-rows-repeated="1048571"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>`
+
+func TestODSService_ReadGridFromReader(t *testing.T) {
+	ods := NewODSService()
+
+	grid, err := ods.ReadGridFromReader(bytes.NewReader(createODS(t, odsCalendar)), Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Calendario", grid.Sheet)
+	assert.Equal(t, [][]string{
+		{"1ª Giornata"},
+		{},
+		{},
+		{"Real Madrink", "72.5", "66", "Atletico Ma  Non Troppo", "2-1"},
+	}, grid.Values())
+	assert.Equal(t, []MergedRange{{Start: "A1", End: "E1", Value: "1ª Giornata"}}, grid.Merged)
+
+	assert.Equal(t, Cell{Ref: "A4", Row: 4, Column: 1, Value: "Real Madrink", Type: CellTypeString}, grid.Rows[3][0])
+	assert.Equal(t, Cell{Ref: "B4", Row: 4, Column: 2, Value: "72,5", Type: CellTypeNumber, Number: 72.5}, grid.Rows[3][1])
+	assert.Equal(t, Cell{Ref: "C4", Row: 4, Column: 3, Value: "66", Type: CellTypeNumber, Number: 66, Formula: "[.B4]-6.5"}, grid.Rows[3][2])
+}
+
+func TestODSService_TooManyCells(t *testing.T) {
+	ods := NewODSService()
+	// A few bytes repeating a filled cell across the whole sheet.
+	bomb := `<table:table table:name="Calendario">
+<table:table-row table:number-rows-repeated="1000000">
+	<table:table-cell table:number-columns-repeated="16384" office:value-type="string"><text:p>x</text:p></table:table-cell>
+</table:table-row>
+</table:table>`
+
+	_, err := ods.ReadGridFromReader(bytes.NewReader(createODS(t, bomb)), Options{})
+	assert.ErrorContains(t, err, "document has more than 1000000 cells")
+}
+
+func TestODSService_RepeatOverflow(t *testing.T) {
+	ods := NewODSService()
+	tests := []struct {
+		name  string
+		table string
+		err   string
+	}{
+		{
+			name: "Columns",
+			table: `<table:table table:name="Calendario"><table:table-row>
+	<table:table-cell table:number-columns-repeated="9223372036854775807"/>
+	<table:table-cell office:value-type="string"><text:p>x</text:p></table:table-cell>
+</table:table-row></table:table>`,
+			err: "row has more than 16384 columns",
+		},
+		{
+			name: "Columns past the integer range",
+			table: `<table:table table:name="Calendario"><table:table-row>
+	<table:table-cell table:number-columns-repeated="99999999999999999999"/>
+	<table:table-cell office:value-type="string"><text:p>x</text:p></table:table-cell>
+</table:table-row></table:table>`,
+			err: "row has more than 16384 columns",
+		},
+		{
+			name: "Rows",
+			table: `<table:table table:name="Calendario">
+<table:table-row table:number-rows-repeated="9223372036854775807"><table:table-cell/></table:table-row>
+<table:table-row table:number-rows-repeated="9223372036854775807"><table:table-cell/></table:table-row>
+<table:table-row><table:table-cell office:value-type="string"><text:p>x</text:p></table:table-cell></table:table-row>
+</table:table>`,
+			err: "sheet 'Calendario' has more than 1048576 rows",
+		},
+		{
+			name: "Cells",
+			table: `<table:table table:name="Calendario"><table:table-row table:number-rows-repeated="9223372036854775807">
+	<table:table-cell table:number-columns-repeated="9223372036854775807" office:value-type="string"><text:p>x</text:p></table:table-cell>
+</table:table-row></table:table>`,
+			err: "document has more than 1000000 cells",
+		},
+		{
+			name: "Spaces",
+			table: `<table:table table:name="Calendario"><table:table-row>
+	<table:table-cell office:value-type="string"><text:p>x<text:s text:c="1500000000"/></text:p></table:table-cell>
+</table:table-row></table:table>`,
+			err: "cell text is longer than 32767 characters",
+		},
+		{
+			name: "Text spread over paragraphs",
+			table: `<table:table table:name="Calendario"><table:table-row>
+	<table:table-cell office:value-type="string"><text:p><text:s text:c="20000"/></text:p><text:p><text:s text:c="20000"/></text:p></table:table-cell>
+</table:table-row></table:table>`,
+			err: "cell text is longer than 32767 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ods.ReadGridFromReader(bytes.NewReader(createODS(t, tt.table)), Options{})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestODSService_CellTypes(t *testing.T) {
+	ods := NewODSService()
+
+	data := createODS(t, `<table:table table:name="Foglio1"><table:table-row>
+		<table:table-cell office:value-type="date" office:date-value="2024-08-18"><text:p>18/08/24</text:p></table:table-cell>
+		<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>VERO</text:p></table:table-cell>
+		<table:table-cell/>
+		<table:table-cell office:value-type="percentage" office:value="0.5"><text:p>50%</text:p></table:table-cell>
+		<table:table-cell><text:p>Riga uno</text:p><text:p>Riga due</text:p></table:table-cell>
+	</table:table-row></table:table>`)
+
+	grid, err := ods.ReadGridFromReader(bytes.NewReader(data), Options{})
+	require.NoError(t, err)
+
+	row := grid.Rows[0]
+	assert.Equal(t, CellTypeDate, row[0].Type)
+	assert.Equal(t, time.Date(2024, 8, 18, 0, 0, 0, 0, time.UTC), row[0].Time)
+	assert.Equal(t, "18/08/24", row[0].Value)
+	assert.Equal(t, CellTypeBool, row[1].Type)
+	assert.True(t, row[1].Bool)
+	assert.Equal(t, Cell{Ref: "C1", Row: 1, Column: 3, Type: CellTypeEmpty}, row[2])
+	assert.Equal(t, CellTypeNumber, row[3].Type)
+	assert.Equal(t, 0.5, row[3].Number)
+	assert.Equal(t, Cell{Ref: "E1", Row: 1, Column: 5, Value: "Riga uno\nRiga due", Type: CellTypeString}, row[4])
+}
+
+func TestODSService_SheetSelection(t *testing.T) {
+	ods := NewODSService()
+
+	data := createODS(t, `<table:table table:name="Note">
+		<table:table-row><table:table-cell office:value-type="string"><text:p>Quote pagate</text:p></table:table-cell></table:table-row>
+	</table:table>`+odsCalendar)
+
+	tests := []struct {
+		name        string
+		sheet       string
+		expected    string
+		expectedErr error
+	}{
+		{name: "Calendar is detected", sheet: "", expected: "Calendario"},
+		{name: "By name", sheet: "note", expected: "Note"},
+		{name: "By position", sheet: "2", expected: "Calendario"},
+		{name: "Unknown sheet", sheet: "Classifica", expectedErr: ErrSheetNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, err := ods.ReadGridFromReader(bytes.NewReader(data), Options{Sheet: tt.sheet})
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, grid.Sheet)
+		})
+	}
+}
+
+func TestODSService_ReadExcelFromReader(t *testing.T) {
+	ods := NewODSService()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    [][]string
+		expectedErr string
+	}{
+		{
+			name: "Same rows as the Excel reader",
+			data: createODS(t, odsCalendar),
+			expected: [][]string{
+				{"1ª Giornata"},
+				{"Real Madrink", "72,5", "66", "Atletico Ma  Non Troppo", "2-1"},
+			},
+		},
+		{
+			name:        "Empty sheet",
+			data:        createODS(t, `<table:table table:name="Foglio1"><table:table-row><table:table-cell table:number-columns-repeated="1024"/></table:table-row></table:table>`),
+			expectedErr: "excel: file contains no valid data rows after filtering",
+		},
+		{
+			name:        "Not a zip file",
+			data:        []byte("this is not a spreadsheet"),
+			expectedErr: "excel: failed to open OpenDocument file",
+		},
+		{
+			name:        "No sheets",
+			data:        createODS(t, ""),
+			expectedErr: "excel: no sheets found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ods.ReadExcelFromReader(bytes.NewReader(tt.data))
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, rows)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rows)
+		})
+	}
+}
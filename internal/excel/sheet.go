@@ -0,0 +1,83 @@
+package excel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrSheetNotFound is returned when the requested sheet is not in the
+// workbook.
+var ErrSheetNotFound = errors.New("excel: sheet not found")
+
+var (
+	calendarResultPattern = regexp.MustCompile(`^\s*\d+\s*-\s*\d+\s*$`)
+	calendarMarkerPattern = regexp.MustCompile(`(?i)giornata`)
+	calendarNamePattern   = regexp.MustCompile(`(?i)calendar`)
+)
+
+// Options selects what to read from a workbook.
+type Options struct {
+	// Sheet is the name or the 1-based position of the sheet to read. When
+	// empty, the sheet that looks the most like a calendar is read.
+	Sheet string
+}
+
+// selectSheet resolves the requested sheet against the workbook sheets. A
+// name takes precedence over a position, so a sheet called "2" can still be
+// picked by name. rows is only called when the calendar has to be looked
+// for.
+func selectSheet(sheets []string, requested string, rows func(sheet string) ([][]string, error)) (string, error) {
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("excel: no sheets found in the Excel file")
+	}
+
+	requested = strings.TrimSpace(requested)
+	if requested != "" {
+		for _, sheet := range sheets {
+			if strings.EqualFold(sheet, requested) {
+				return sheet, nil
+			}
+		}
+		if index, err := strconv.Atoi(requested); err == nil && index >= 1 && index <= len(sheets) {
+			return sheets[index-1], nil
+		}
+		return "", fmt.Errorf("%w: %q, the workbook has %s", ErrSheetNotFound, requested, strings.Join(sheets, ", "))
+	}
+
+	if len(sheets) == 1 {
+		return sheets[0], nil
+	}
+
+	best, bestScore := sheets[0], 0
+	for _, sheet := range sheets {
+		sheetRows, err := rows(sheet)
+		if err != nil {
+			return "", fmt.Errorf("excel: failed to get rows from sheet '%s': %w", sheet, err)
+		}
+		if score := calendarScore(sheet, sheetRows); score > bestScore {
+			best, bestScore = sheet, score
+		}
+	}
+	return best, nil
+}
+
+// calendarScore tells how much a sheet looks like a calendar: every result
+// like "2-1" and every matchday marker counts, and a name such as
+// "Calendario" breaks ties between sheets without any.
+func calendarScore(sheet string, rows [][]string) int {
+	score := 0
+	for _, row := range rows {
+		for _, cell := range row {
+			if calendarResultPattern.MatchString(cell) || calendarMarkerPattern.MatchString(cell) {
+				score += 2
+			}
+		}
+	}
+	if calendarNamePattern.MatchString(sheet) {
+		score++
+	}
+	return score
+}
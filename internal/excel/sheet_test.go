@@ -0,0 +1,117 @@
+package excel
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// newLeagueWorkbook builds a workbook where the calendar is not the first
+// sheet.
+func newLeagueWorkbook(t *testing.T) []byte {
+	t.Helper()
+
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetName("Sheet1", "Classifica"))
+	require.NoError(t, f.SetSheetRow("Classifica", "A1", &[]any{"Squadra", "Punti"}))
+	require.NoError(t, f.SetSheetRow("Classifica", "A2", &[]any{"TeamA", 3}))
+
+	_, err := f.NewSheet("Calendario")
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetRow("Calendario", "A1", &[]any{"1a Giornata lega"}))
+	require.NoError(t, f.SetSheetRow("Calendario", "A2", &[]any{"TeamA", 72.5, 66, "TeamB", "2-1"}))
+
+	_, err = f.NewSheet("Coppa")
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetRow("Coppa", "A1", &[]any{"TeamC", 60, 70, "TeamD", "0-1"}))
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+	return buf.Bytes()
+}
+
+func TestExcelService_SheetSelection(t *testing.T) {
+	es := ExcelServiceImpl{}
+	workbook := newLeagueWorkbook(t)
+
+	tests := []struct {
+		name        string
+		opts        Options
+		wantSheet   string
+		wantFirst   string
+		wantMissing bool
+	}{
+		{name: "Calendar Found By Content", opts: Options{}, wantSheet: "Calendario", wantFirst: "1a Giornata lega"},
+		{name: "By Name", opts: Options{Sheet: "Coppa"}, wantSheet: "Coppa", wantFirst: "TeamC"},
+		{name: "By Name Ignoring Case", opts: Options{Sheet: "classifica"}, wantSheet: "Classifica", wantFirst: "Squadra"},
+		{name: "By Position", opts: Options{Sheet: "1"}, wantSheet: "Classifica", wantFirst: "Squadra"},
+		{name: "Unknown Name", opts: Options{Sheet: "Mercato"}, wantMissing: true},
+		{name: "Position Out Of Range", opts: Options{Sheet: "4"}, wantMissing: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, err := es.ReadGridFromReader(bytes.NewReader(workbook), tt.opts)
+
+			if tt.wantMissing {
+				assert.True(t, errors.Is(err, ErrSheetNotFound), "Expected ErrSheetNotFound, got %v", err)
+				assert.Nil(t, grid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSheet, grid.Sheet)
+			assert.Equal(t, tt.wantFirst, grid.Rows[0][0].Value)
+		})
+	}
+}
+
+func TestExcelService_ReadExcelFromReaderFindsCalendar(t *testing.T) {
+	es := ExcelServiceImpl{}
+
+	rows, err := es.ReadExcelFromReader(bytes.NewReader(newLeagueWorkbook(t)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1a Giornata lega"}, rows[0])
+}
+
+func TestSelectSheet(t *testing.T) {
+	noRows := func(sheet string) ([][]string, error) { return nil, nil }
+
+	tests := []struct {
+		name      string
+		sheets    []string
+		requested string
+		rows      func(sheet string) ([][]string, error)
+		want      string
+		wantErr   bool
+	}{
+		{name: "No Sheets", sheets: nil, wantErr: true},
+		{name: "Single Sheet", sheets: []string{"Foglio1"}, rows: noRows, want: "Foglio1"},
+		{name: "Name Before Position", sheets: []string{"2", "Other"}, requested: "2", want: "2"},
+		{name: "Calendar Name Breaks Ties", sheets: []string{"Rose", "Calendario"}, rows: noRows, want: "Calendario"},
+		{name: "First Sheet When Nothing Matches", sheets: []string{"Rose", "Mercato"}, rows: noRows, want: "Rose"},
+		{
+			name:   "Rows Error",
+			sheets: []string{"A", "B"},
+			rows: func(sheet string) ([][]string, error) {
+				return nil, errors.New("broken sheet")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectSheet(tt.sheets, tt.requested, tt.rows)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,692 @@
+package excel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"github.com/xuri/excelize/v2"
+)
+
+// BIFF8 record types read from the workbook stream.
+const (
+	biffFormula    = 0x0006
+	biffEOF        = 0x000A
+	biffFilePass   = 0x002F
+	biffDate1904   = 0x0022
+	biffContinue   = 0x003C
+	biffBoundSheet = 0x0085
+	biffMulRK      = 0x00BD
+	biffXF         = 0x00E0
+	biffMergeCells = 0x00E5
+	biffSST        = 0x00FC
+	biffLabelSST   = 0x00FD
+	biffNumber     = 0x0203
+	biffLabel      = 0x0204
+	biffBoolErr    = 0x0205
+	biffString     = 0x0207
+	biffRK         = 0x027E
+	biffFormat     = 0x041E
+	biffBOF        = 0x0809
+)
+
+const (
+	biff8Version       = 0x0600
+	biffSheetGlobals   = 0x0005
+	biffSheetWorksheet = 0x0010
+)
+
+// xlsMaxColumns is the width of a BIFF8 sheet, columns A to IV.
+const xlsMaxColumns = 256
+
+// biffErrors are the texts of the error codes of BOOLERR and FORMULA records.
+var biffErrors = map[byte]string{
+	0x00: "#NULL!", 0x07: "#DIV/0!", 0x0F: "#VALUE!", 0x17: "#REF!", 0x1D: "#NAME?", 0x24: "#NUM!", 0x2A: "#N/A",
+}
+
+// XLSServiceImpl reads calendars kept as legacy Excel 97-2003 workbooks
+// (.xls, BIFF8). It covers what calendar exports use: shared strings, labels,
+// numbers and the cached value of formulas, whose text is not read back.
+type XLSServiceImpl struct{}
+
+func NewXLSService() *XLSServiceImpl {
+	return &XLSServiceImpl{}
+}
+
+// biffRecord is a record with the data of the CONTINUE records following it.
+type biffRecord struct {
+	kind     uint16
+	segments [][]byte
+}
+
+func (r biffRecord) data() []byte {
+	return r.segments[0]
+}
+
+// xlsWorkbook is what the workbook globals tell about the sheets.
+type xlsWorkbook struct {
+	sst      []string
+	formats  map[uint16]string
+	xfs      []uint16
+	date1904 bool
+}
+
+// xlsBoundSheet is a sheet listed in the globals, with the stream offset of
+// its BOF record.
+type xlsBoundSheet struct {
+	name   string
+	offset int
+}
+
+func (xs *XLSServiceImpl) ReadExcelFromReader(reader io.Reader) ([][]string, error) {
+	grid, err := xs.ReadGridFromReader(reader, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredRows [][]string
+	for _, row := range grid.Rows {
+		var newRow []string
+		for _, cell := range row {
+			if cell.Value != "" {
+				newRow = append(newRow, cell.Value)
+			}
+		}
+		if len(newRow) > 0 {
+			filteredRows = append(filteredRows, newRow)
+		}
+	}
+
+	return filteredRows, nil
+}
+
+func (xs *XLSServiceImpl) ReadExcel(fileHeader FileHeaderOpener) ([][]string, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return xs.ReadExcelFromReader(file)
+}
+
+// ReadGridFromReader reads the selected sheet in grid mode. Numbers are
+// displayed in full and dates in ISO form, as the number formats of the sheet
+// are only used to tell dates apart.
+func (xs *XLSServiceImpl) ReadGridFromReader(reader io.Reader, opts Options) (*Grid, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("excel: reader is nil")
+	}
+
+	stream, err := readWorkbookStream(reader)
+	if err != nil {
+		return nil, err
+	}
+	workbook, sheets, err := readXLSGlobals(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(sheets))
+	for i, sheet := range sheets {
+		names[i] = sheet.name
+	}
+	grids := make(map[string]*Grid, len(sheets))
+	sheetGrid := func(name string) (*Grid, error) {
+		if grid, ok := grids[name]; ok {
+			return grid, nil
+		}
+		for _, sheet := range sheets {
+			if sheet.name == name {
+				grid, err := workbook.readSheet(stream, sheet)
+				if err != nil {
+					return nil, fmt.Errorf("excel: failed to get rows from sheet '%s': %w", name, err)
+				}
+				grids[name] = grid
+				return grid, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %q", ErrSheetNotFound, name)
+	}
+
+	sheetName, err := selectSheet(names, opts.Sheet, func(sheet string) ([][]string, error) {
+		grid, err := sheetGrid(sheet)
+		if err != nil {
+			return nil, err
+		}
+		return grid.Values(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	grid, err := sheetGrid(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range grid.Rows {
+		for _, cell := range row {
+			if cell.Value != "" {
+				return grid, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("excel: file contains no valid data rows after filtering")
+}
+
+func (xs *XLSServiceImpl) ReadGrid(fileHeader FileHeaderOpener, opts Options) (*Grid, error) {
+	if fileHeader == nil {
+		return nil, fmt.Errorf("file header is nil")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return xs.ReadGridFromReader(file, opts)
+}
+
+// readWorkbookStream returns the Workbook stream of the compound file.
+func readWorkbookStream(reader io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to read xls file: %w", err)
+	}
+	doc, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("excel: failed to open xls file: %w", err)
+	}
+
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		switch {
+		case strings.EqualFold(entry.Name, "Workbook"):
+			stream, err := io.ReadAll(entry)
+			if err != nil {
+				return nil, fmt.Errorf("excel: failed to read xls workbook stream: %w", err)
+			}
+			return stream, nil
+		case strings.EqualFold(entry.Name, "Book"):
+			return nil, fmt.Errorf("excel: xls files older than Excel 97 are not supported")
+		}
+	}
+	return nil, fmt.Errorf("excel: xls file has no workbook stream, it may be encrypted")
+}
+
+// readXLSGlobals reads the shared strings, the formats and the sheet list
+// from the workbook globals at the start of the stream.
+func readXLSGlobals(stream []byte) (*xlsWorkbook, []xlsBoundSheet, error) {
+	workbook := &xlsWorkbook{formats: make(map[uint16]string)}
+	var sheets []xlsBoundSheet
+
+	records := biffRecords(stream, 0)
+	for {
+		record, err := records()
+		if err != nil {
+			return nil, nil, err
+		}
+		data := record.data()
+
+		switch record.kind {
+		case biffBOF:
+			if len(data) < 4 || binary.LittleEndian.Uint16(data) != biff8Version {
+				return nil, nil, fmt.Errorf("excel: only Excel 97-2003 (BIFF8) xls files are supported")
+			}
+			if binary.LittleEndian.Uint16(data[2:]) != biffSheetGlobals {
+				return nil, nil, fmt.Errorf("excel: xls workbook stream does not start with the workbook globals")
+			}
+		case biffFilePass:
+			return nil, nil, fmt.Errorf("excel: xls file is password protected")
+		case biffDate1904:
+			workbook.date1904 = len(data) >= 2 && binary.LittleEndian.Uint16(data) == 1
+		case biffFormat:
+			if len(data) < 2 {
+				continue
+			}
+			code, err := newBiffReader(record.segments, 2).unicodeString(2)
+			if err != nil {
+				return nil, nil, fmt.Errorf("excel: invalid xls number format: %w", err)
+			}
+			workbook.formats[binary.LittleEndian.Uint16(data)] = code
+		case biffXF:
+			if len(data) >= 4 {
+				workbook.xfs = append(workbook.xfs, binary.LittleEndian.Uint16(data[2:]))
+			}
+		case biffSST:
+			if workbook.sst, err = readSST(record); err != nil {
+				return nil, nil, fmt.Errorf("excel: invalid xls shared strings: %w", err)
+			}
+		case biffBoundSheet:
+			if len(data) < 6 || data[5] != 0 {
+				// Charts, macro and dialog sheets hold no cells.
+				continue
+			}
+			name, err := newBiffReader(record.segments, 6).unicodeString(1)
+			if err != nil {
+				return nil, nil, fmt.Errorf("excel: invalid xls sheet name: %w", err)
+			}
+			sheets = append(sheets, xlsBoundSheet{name: name, offset: int(binary.LittleEndian.Uint32(data))})
+		case biffEOF:
+			return workbook, sheets, nil
+		}
+	}
+}
+
+// readSheet reads the cells of a worksheet substream into a grid.
+func (w *xlsWorkbook) readSheet(stream []byte, sheet xlsBoundSheet) (*Grid, error) {
+	cells := make(map[[2]int]Cell)
+	grid := &Grid{Sheet: sheet.name}
+	var pendingString *Cell
+
+	// set records the first cell past the sheet as the error of the record.
+	var invalid error
+	set := func(cell Cell) {
+		if cell.Column > xlsMaxColumns {
+			if invalid == nil {
+				invalid = fmt.Errorf("cell at row %d, column %d is past column %d", cell.Row, cell.Column, xlsMaxColumns)
+			}
+			return
+		}
+		cells[[2]int{cell.Row, cell.Column}] = cell
+	}
+
+	records := biffRecords(stream, sheet.offset)
+	for {
+		if invalid != nil {
+			return nil, invalid
+		}
+		record, err := records()
+		if err != nil {
+			return nil, err
+		}
+		data := record.data()
+
+		switch record.kind {
+		case biffBOF:
+			if len(data) < 4 || binary.LittleEndian.Uint16(data[2:]) != biffSheetWorksheet {
+				return nil, fmt.Errorf("sheet does not start with a worksheet BOF record")
+			}
+		case biffLabelSST:
+			if len(data) < 10 {
+				continue
+			}
+			index := int(binary.LittleEndian.Uint32(data[6:]))
+			if index >= len(w.sst) {
+				return nil, fmt.Errorf("shared string %d out of %d", index, len(w.sst))
+			}
+			set(w.textCell(data, w.sst[index]))
+		case biffLabel:
+			if len(data) < 6 {
+				continue
+			}
+			text, err := newBiffReader(record.segments, 6).unicodeString(2)
+			if err != nil {
+				return nil, fmt.Errorf("invalid label: %w", err)
+			}
+			set(w.textCell(data, text))
+		case biffNumber:
+			if len(data) < 14 {
+				continue
+			}
+			set(w.numberCell(data, binary.LittleEndian.Uint16(data[4:]), math.Float64frombits(binary.LittleEndian.Uint64(data[6:]))))
+		case biffRK:
+			if len(data) < 10 {
+				continue
+			}
+			set(w.numberCell(data, binary.LittleEndian.Uint16(data[4:]), decodeRK(binary.LittleEndian.Uint32(data[6:]))))
+		case biffMulRK:
+			// Row, first column, then ixfe and RK pairs up to the last column.
+			if len(data) < 6 {
+				continue
+			}
+			if last := int(binary.LittleEndian.Uint16(data[len(data)-2:])) + 1; last > xlsMaxColumns {
+				return nil, fmt.Errorf("cells up to column %d are past column %d", last, xlsMaxColumns)
+			}
+			for i, offset := 0, 4; offset+6 <= len(data)-2; i, offset = i+1, offset+6 {
+				cell := w.numberCell(data, binary.LittleEndian.Uint16(data[offset:]), decodeRK(binary.LittleEndian.Uint32(data[offset+2:])))
+				cell.Column += i
+				cell.Ref = cellRef(cell.Column, cell.Row)
+				set(cell)
+			}
+		case biffBoolErr:
+			if len(data) < 8 {
+				continue
+			}
+			set(w.boolErrCell(data, data[6], data[7] == 1))
+		case biffFormula:
+			if len(data) < 14 {
+				continue
+			}
+			if binary.LittleEndian.Uint16(data[12:]) != 0xFFFF {
+				set(w.numberCell(data, binary.LittleEndian.Uint16(data[4:]), math.Float64frombits(binary.LittleEndian.Uint64(data[6:]))))
+				continue
+			}
+			switch data[6] {
+			case 0:
+				// The string value comes in the STRING record that follows.
+				cell := w.textCell(data, "")
+				pendingString = &cell
+			case 1:
+				set(w.boolErrCell(data, data[8], false))
+			case 2:
+				set(w.boolErrCell(data, data[8], true))
+			}
+		case biffString:
+			if pendingString == nil {
+				continue
+			}
+			text, err := newBiffReader(record.segments, 0).unicodeString(2)
+			if err != nil {
+				return nil, fmt.Errorf("invalid formula string: %w", err)
+			}
+			if text != "" {
+				pendingString.Value, pendingString.Type = text, CellTypeString
+				set(*pendingString)
+			}
+			pendingString = nil
+		case biffMergeCells:
+			for offset := 2; offset+8 <= len(data); offset += 8 {
+				firstRow, lastRow := int(binary.LittleEndian.Uint16(data[offset:])), int(binary.LittleEndian.Uint16(data[offset+2:]))
+				firstColumn, lastColumn := int(binary.LittleEndian.Uint16(data[offset+4:])), int(binary.LittleEndian.Uint16(data[offset+6:]))
+				grid.Merged = append(grid.Merged, MergedRange{
+					Start: cellRef(firstColumn+1, firstRow+1),
+					End:   cellRef(lastColumn+1, lastRow+1),
+				})
+			}
+		case biffEOF:
+			if err := layOutXLSCells(grid, cells); err != nil {
+				return nil, err
+			}
+			return grid, nil
+		}
+	}
+}
+
+// cellPosition returns the 1-based row and column of a cell record.
+func cellPosition(data []byte) (int, int) {
+	return int(binary.LittleEndian.Uint16(data)) + 1, int(binary.LittleEndian.Uint16(data[2:])) + 1
+}
+
+func (w *xlsWorkbook) textCell(data []byte, text string) Cell {
+	row, column := cellPosition(data)
+	cell := Cell{Ref: cellRef(column, row), Row: row, Column: column, Value: text, Type: CellTypeString}
+	if text == "" {
+		cell.Type = CellTypeEmpty
+	}
+	return cell
+}
+
+// numberCell types a number as a date when its format shows one.
+func (w *xlsWorkbook) numberCell(data []byte, xf uint16, number float64) Cell {
+	row, column := cellPosition(data)
+	cell := Cell{Ref: cellRef(column, row), Row: row, Column: column}
+
+	if w.isDate(xf) {
+		if t, err := excelize.ExcelDateToTime(number, w.date1904); err == nil {
+			cell.Type, cell.Time = CellTypeDate, t
+			cell.Value = t.Format("2006-01-02")
+			if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+				cell.Value = t.Format("2006-01-02 15:04:05")
+			}
+			return cell
+		}
+	}
+
+	cell.Type, cell.Number = CellTypeNumber, number
+	cell.Value = strconv.FormatFloat(number, 'f', -1, 64)
+	return cell
+}
+
+func (w *xlsWorkbook) boolErrCell(data []byte, value byte, isError bool) Cell {
+	row, column := cellPosition(data)
+	cell := Cell{Ref: cellRef(column, row), Row: row, Column: column}
+
+	if isError {
+		cell.Type, cell.Value = CellTypeError, biffErrors[value]
+		return cell
+	}
+	cell.Type, cell.Bool, cell.Value = CellTypeBool, value == 1, "FALSE"
+	if cell.Bool {
+		cell.Value = "TRUE"
+	}
+	return cell
+}
+
+// isDate tells whether the cell format at index xf shows a date.
+func (w *xlsWorkbook) isDate(xf uint16) bool {
+	if int(xf) >= len(w.xfs) {
+		return false
+	}
+	format := w.xfs[xf]
+	if code, ok := w.formats[format]; ok {
+		return isDateFormatCode(code)
+	}
+	return dateNumFmts[int(format)]
+}
+
+// layOutXLSCells turns the cells read from the sheet into grid rows, filling
+// the gaps with empty cells. Empty rows are kept, trailing empty cells are
+// not. Sheets that would lay out more than maxGridCells cells are an error.
+func layOutXLSCells(grid *Grid, cells map[[2]int]Cell) error {
+	lastRow := 0
+	lastColumns := make(map[int]int)
+	for position, cell := range cells {
+		if cell.Type == CellTypeEmpty {
+			continue
+		}
+		lastRow = max(lastRow, position[0])
+		lastColumns[position[0]] = max(lastColumns[position[0]], position[1])
+	}
+	laidOut := 0
+	for _, columns := range lastColumns {
+		if laidOut += columns; laidOut > maxGridCells {
+			return fmt.Errorf("sheet '%s' has more than %d cells", grid.Sheet, maxGridCells)
+		}
+	}
+
+	grid.Rows = make([][]Cell, lastRow)
+	for row := 1; row <= lastRow; row++ {
+		grid.Rows[row-1] = make([]Cell, lastColumns[row])
+		for column := 1; column <= lastColumns[row]; column++ {
+			cell, ok := cells[[2]int{row, column}]
+			if !ok {
+				cell = Cell{Ref: cellRef(column, row), Row: row, Column: column, Type: CellTypeEmpty}
+			}
+			grid.Rows[row-1][column-1] = cell
+		}
+	}
+	return nil
+}
+
+// decodeRK decodes an RK number: a 30 bits integer or the top bits of a
+// float, optionally multiplied by 100.
+func decodeRK(rk uint32) float64 {
+	var number float64
+	if rk&0x02 != 0 {
+		number = float64(int32(rk) >> 2)
+	} else {
+		number = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		number /= 100
+	}
+	return number
+}
+
+// biffRecords returns an iterator over the records of the stream starting at
+// offset, each with its CONTINUE records.
+func biffRecords(stream []byte, offset int) func() (biffRecord, error) {
+	readHeader := func() (uint16, []byte, error) {
+		if offset+4 > len(stream) {
+			return 0, nil, fmt.Errorf("excel: xls workbook stream ends without an EOF record")
+		}
+		kind := binary.LittleEndian.Uint16(stream[offset:])
+		length := int(binary.LittleEndian.Uint16(stream[offset+2:]))
+		if offset+4+length > len(stream) {
+			return 0, nil, fmt.Errorf("excel: xls record 0x%04X overruns the workbook stream", kind)
+		}
+		data := stream[offset+4 : offset+4+length]
+		offset += 4 + length
+		return kind, data, nil
+	}
+
+	return func() (biffRecord, error) {
+		kind, data, err := readHeader()
+		if err != nil {
+			return biffRecord{}, err
+		}
+		record := biffRecord{kind: kind, segments: [][]byte{data}}
+
+		for offset+4 <= len(stream) && binary.LittleEndian.Uint16(stream[offset:]) == biffContinue {
+			_, data, err := readHeader()
+			if err != nil {
+				return biffRecord{}, err
+			}
+			record.segments = append(record.segments, data)
+		}
+		return record, nil
+	}
+}
+
+// biffReader reads the data of a record across its CONTINUE segments.
+type biffReader struct {
+	segments [][]byte
+	segment  int
+	pos      int
+}
+
+func newBiffReader(segments [][]byte, pos int) *biffReader {
+	return &biffReader{segments: segments, pos: pos}
+}
+
+// next returns the next n bytes, which may span segments.
+func (r *biffReader) next(n int) ([]byte, error) {
+	var out []byte
+	for n > 0 {
+		if r.segment >= len(r.segments) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		segment := r.segments[r.segment]
+		if r.pos >= len(segment) {
+			r.segment, r.pos = r.segment+1, 0
+			continue
+		}
+		take := min(n, len(segment)-r.pos)
+		out = append(out, segment[r.pos:r.pos+take]...)
+		r.pos += take
+		n -= take
+	}
+	return out, nil
+}
+
+func (r *biffReader) uint16() (int, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint16(b)), nil
+}
+
+// unicodeString reads an XLUnicodeString, or with a lengthSize of 1 a
+// ShortXLUnicodeString, skipping any rich text and phonetic data.
+func (r *biffReader) unicodeString(lengthSize int) (string, error) {
+	lengthBytes, err := r.next(lengthSize)
+	if err != nil {
+		return "", err
+	}
+	length := int(lengthBytes[0])
+	if lengthSize == 2 {
+		length = int(binary.LittleEndian.Uint16(lengthBytes))
+	}
+	flags, err := r.next(1)
+	if err != nil {
+		return "", err
+	}
+
+	runs, extSize := 0, 0
+	if flags[0]&0x08 != 0 {
+		if runs, err = r.uint16(); err != nil {
+			return "", err
+		}
+	}
+	if flags[0]&0x04 != 0 {
+		b, err := r.next(4)
+		if err != nil {
+			return "", err
+		}
+		extSize = int(binary.LittleEndian.Uint32(b))
+	}
+
+	text, err := r.characters(length, flags[0]&0x01 != 0)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.next(4*runs + extSize); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// characters reads length characters, compressed to one byte each or UTF-16.
+// When they continue in the next segment, it starts with a new flags byte
+// telling how the rest is stored.
+func (r *biffReader) characters(length int, wide bool) (string, error) {
+	units := make([]uint16, 0, length)
+	for len(units) < length {
+		if r.segment >= len(r.segments) {
+			return "", io.ErrUnexpectedEOF
+		}
+		segment := r.segments[r.segment]
+		if r.pos >= len(segment) {
+			r.segment, r.pos = r.segment+1, 0
+			if r.segment >= len(r.segments) || len(r.segments[r.segment]) == 0 {
+				return "", io.ErrUnexpectedEOF
+			}
+			wide = r.segments[r.segment][0]&0x01 != 0
+			r.pos = 1
+			continue
+		}
+
+		if wide {
+			if r.pos+2 > len(segment) {
+				return "", io.ErrUnexpectedEOF
+			}
+			units = append(units, binary.LittleEndian.Uint16(segment[r.pos:]))
+			r.pos += 2
+		} else {
+			units = append(units, uint16(segment[r.pos]))
+			r.pos++
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// readSST reads the shared strings table.
+func readSST(record biffRecord) ([]string, error) {
+	if len(record.data()) < 8 {
+		return nil, fmt.Errorf("record too short")
+	}
+	count := int(binary.LittleEndian.Uint32(record.data()[4:]))
+
+	reader := newBiffReader(record.segments, 8)
+	sst := make([]string, 0, min(count, 65536))
+	for i := 0; i < count; i++ {
+		text, err := reader.unicodeString(2)
+		if err != nil {
+			return nil, fmt.Errorf("string %d of %d: %w", i+1, count, err)
+		}
+		sst = append(sst, text)
+	}
+	return sst, nil
+}
@@ -0,0 +1,439 @@
+package excel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// biffRec encodes a BIFF8 record.
+func biffRec(kind uint16, parts ...[]byte) []byte {
+	data := bytes.Join(parts, nil)
+	record := binary.LittleEndian.AppendUint16(nil, kind)
+	record = binary.LittleEndian.AppendUint16(record, uint16(len(data)))
+	return append(record, data...)
+}
+
+func le16(values ...int) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
+
+func le32(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// xlString encodes the characters of a string, compressed when they all fit
+// in a byte, with the flags byte in front.
+func xlString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	compressed := true
+	for _, u := range units {
+		if u > 0xFF {
+			compressed = false
+		}
+	}
+	if compressed {
+		b := []byte{0}
+		for _, u := range units {
+			b = append(b, byte(u))
+		}
+		return b
+	}
+	b := []byte{1}
+	for _, u := range units {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+// xlUnicodeString encodes an XLUnicodeString, its length in characters
+// followed by the characters.
+func xlUnicodeString(s string) []byte {
+	return append(le16(len(utf16.Encode([]rune(s)))), xlString(s)...)
+}
+
+func xlsLabelSST(row, column, xf int, index uint32) []byte {
+	return biffRec(biffLabelSST, le16(row, column, xf), le32(index))
+}
+
+func xlsNumber(row, column, xf int, number float64) []byte {
+	return biffRec(biffNumber, le16(row, column, xf), binary.LittleEndian.AppendUint64(nil, math.Float64bits(number)))
+}
+
+type xlsTestSheet struct {
+	name    string
+	records [][]byte
+}
+
+// createXLS builds a BIFF8 workbook with the given shared strings and sheets.
+// The globals hold 16 general XFs, then the extra globals records before the
+// shared strings. sstContinue, when set, is a CONTINUE record appended after
+// the SST.
+func createXLS(t *testing.T, sst []string, globals [][]byte, sstContinue []byte, sheets ...xlsTestSheet) []byte {
+	t.Helper()
+
+	var sstData []byte
+	sstData = append(sstData, le32(uint32(len(sst)))...)
+	sstData = append(sstData, le32(uint32(len(sst)))...)
+	for _, s := range sst {
+		sstData = append(sstData, xlUnicodeString(s)...)
+	}
+
+	buildGlobals := func(offsets []int) []byte {
+		var b []byte
+		b = append(b, biffRec(biffBOF, le16(biff8Version, biffSheetGlobals, 0, 0), le32(0), le32(0))...)
+		for i := 0; i < 16; i++ {
+			b = append(b, biffRec(biffXF, le16(0, 0), make([]byte, 16))...)
+		}
+		for _, record := range globals {
+			b = append(b, record...)
+		}
+		b = append(b, biffRec(biffSST, sstData)...)
+		if sstContinue != nil {
+			b = append(b, sstContinue...)
+		}
+		for i, sheet := range sheets {
+			name := xlString(sheet.name)
+			b = append(b, biffRec(biffBoundSheet, le32(uint32(offsets[i])), []byte{0, 0, byte(len(name) - 1)}, name)...)
+		}
+		return append(b, biffRec(biffEOF)...)
+	}
+
+	var sheetStreams [][]byte
+	for _, sheet := range sheets {
+		s := biffRec(biffBOF, le16(biff8Version, biffSheetWorksheet, 0, 0), le32(0), le32(0))
+		for _, record := range sheet.records {
+			s = append(s, record...)
+		}
+		sheetStreams = append(sheetStreams, append(s, biffRec(biffEOF)...))
+	}
+
+	offsets := make([]int, len(sheets))
+	offset := len(buildGlobals(offsets))
+	for i, s := range sheetStreams {
+		offsets[i] = offset
+		offset += len(s)
+	}
+
+	stream := buildGlobals(offsets)
+	for _, s := range sheetStreams {
+		stream = append(stream, s...)
+	}
+	return compoundFile(t, "Workbook", stream)
+}
+
+// compoundFile wraps a stream in a version 3 compound file. The stream is
+// padded to stay out of the mini stream.
+func compoundFile(t *testing.T, name string, stream []byte) []byte {
+	t.Helper()
+
+	const sectorSize = 512
+	const endOfChain, freeSect, fatSect, noStream = 0xFFFFFFFE, 0xFFFFFFFF, 0xFFFFFFFD, 0xFFFFFFFF
+
+	if len(stream) < 4096 {
+		stream = append(stream, make([]byte, 4096-len(stream))...)
+	}
+	sectors := (len(stream) + sectorSize - 1) / sectorSize
+	require.Less(t, sectors+2, sectorSize/4, "test stream too large")
+
+	header := make([]byte, sectorSize)
+	copy(header, oleSignature)
+	binary.LittleEndian.PutUint16(header[24:], 0x003E)
+	binary.LittleEndian.PutUint16(header[26:], 3)
+	binary.LittleEndian.PutUint16(header[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(header[30:], 9)
+	binary.LittleEndian.PutUint16(header[32:], 6)
+	binary.LittleEndian.PutUint32(header[44:], 1)
+	binary.LittleEndian.PutUint32(header[48:], 1)
+	binary.LittleEndian.PutUint32(header[56:], 4096)
+	binary.LittleEndian.PutUint32(header[60:], endOfChain)
+	binary.LittleEndian.PutUint32(header[68:], endOfChain)
+	binary.LittleEndian.PutUint32(header[76:], 0)
+	for i := 80; i < sectorSize; i += 4 {
+		binary.LittleEndian.PutUint32(header[i:], freeSect)
+	}
+
+	fat := make([]byte, sectorSize)
+	for i := 0; i < sectorSize/4; i++ {
+		binary.LittleEndian.PutUint32(fat[i*4:], freeSect)
+	}
+	binary.LittleEndian.PutUint32(fat[0:], fatSect)
+	binary.LittleEndian.PutUint32(fat[4:], endOfChain)
+	for i := 0; i < sectors; i++ {
+		next := uint32(i + 3)
+		if i == sectors-1 {
+			next = endOfChain
+		}
+		binary.LittleEndian.PutUint32(fat[(i+2)*4:], next)
+	}
+
+	entry := func(name string, kind byte, child, start, size uint32) []byte {
+		e := make([]byte, 128)
+		units := utf16.Encode([]rune(name))
+		for i, u := range units {
+			binary.LittleEndian.PutUint16(e[i*2:], u)
+		}
+		binary.LittleEndian.PutUint16(e[64:], uint16((len(units)+1)*2))
+		e[66], e[67] = kind, 1
+		binary.LittleEndian.PutUint32(e[68:], noStream)
+		binary.LittleEndian.PutUint32(e[72:], noStream)
+		binary.LittleEndian.PutUint32(e[76:], child)
+		binary.LittleEndian.PutUint32(e[116:], start)
+		binary.LittleEndian.PutUint32(e[120:], size)
+		return e
+	}
+	directory := append(entry("Root Entry", 5, 1, endOfChain, 0), entry(name, 2, noStream, 2, uint32(len(stream)))...)
+	directory = append(directory, make([]byte, sectorSize-len(directory))...)
+
+	file := append(header, fat...)
+	file = append(file, directory...)
+	file = append(file, stream...)
+	return append(file, make([]byte, sectors*sectorSize-len(stream))...)
+}
+
+func xlsCalendar(t *testing.T) []byte {
+	return createXLS(t,
+		[]string{"1ª Giornata", "Real Madrink", "Atletico Ma Non Troppo", "2-1", "Note", "Quote pagate"},
+		nil,
+		nil,
+		xlsTestSheet{name: "Note", records: [][]byte{xlsLabelSST(0, 0, 15, 5)}},
+		xlsTestSheet{name: "Calendario", records: [][]byte{
+			xlsLabelSST(0, 0, 15, 0),
+			biffRec(biffMergeCells, le16(1), le16(0, 0, 0, 4)),
+			xlsLabelSST(3, 0, 15, 1),
+			xlsNumber(3, 1, 15, 72.5),
+			// 66 as an integer RK, 6650 / 100 as a scaled one.
+			biffRec(biffMulRK, le16(3, 2), le16(15), le32(66<<2|0x02), le16(15), le32(6650<<2|0x03), le16(3)),
+			xlsLabelSST(3, 4, 15, 2),
+			xlsLabelSST(3, 5, 15, 3),
+		}},
+	)
+}
+
+func TestXLSService_ReadExcelFromReader(t *testing.T) {
+	xs := NewXLSService()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    [][]string
+		expectedErr string
+	}{
+		{
+			name: "Calendar sheet",
+			data: xlsCalendar(t),
+			expected: [][]string{
+				{"1ª Giornata"},
+				{"Real Madrink", "72.5", "66", "66.5", "Atletico Ma Non Troppo", "2-1"},
+			},
+		},
+		{
+			name:        "Not an OLE file",
+			data:        []byte("this is not a spreadsheet at all, just some text long enough"),
+			expectedErr: "excel: failed to open xls file",
+		},
+		{
+			name:        "Excel 5 workbook",
+			data:        compoundFile(t, "Book", biffRec(biffEOF)),
+			expectedErr: "older than Excel 97",
+		},
+		{
+			name:        "Encrypted workbook",
+			data:        compoundFile(t, "EncryptedPackage", []byte{0}),
+			expectedErr: "no workbook stream",
+		},
+		{
+			name:        "Password protected",
+			data:        createXLS(t, nil, [][]byte{biffRec(biffFilePass, le16(0))}, nil, xlsTestSheet{name: "Foglio1"}),
+			expectedErr: "password protected",
+		},
+		{
+			name:        "Empty sheet",
+			data:        createXLS(t, nil, nil, nil, xlsTestSheet{name: "Foglio1"}),
+			expectedErr: "excel: file contains no valid data rows after filtering",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := xs.ReadExcelFromReader(bytes.NewReader(tt.data))
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, rows)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rows)
+		})
+	}
+}
+
+func TestXLSService_ReadGridFromReader(t *testing.T) {
+	xs := NewXLSService()
+
+	grid, err := xs.ReadGridFromReader(bytes.NewReader(xlsCalendar(t)), Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Calendario", grid.Sheet)
+	assert.Equal(t, [][]string{
+		{"1ª Giornata"},
+		{},
+		{},
+		{"Real Madrink", "72.5", "66", "66.5", "Atletico Ma Non Troppo", "2-1"},
+	}, grid.Values())
+	assert.Equal(t, []MergedRange{{Start: "A1", End: "E1"}}, grid.Merged)
+	assert.Equal(t, Cell{Ref: "B4", Row: 4, Column: 2, Value: "72.5", Type: CellTypeNumber, Number: 72.5}, grid.Rows[3][1])
+	assert.Equal(t, Cell{Ref: "D4", Row: 4, Column: 4, Value: "66.5", Type: CellTypeNumber, Number: 66.5}, grid.Rows[3][3])
+
+	grid, err = xs.ReadGridFromReader(bytes.NewReader(xlsCalendar(t)), Options{Sheet: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Note", grid.Sheet)
+
+	_, err = xs.ReadGridFromReader(bytes.NewReader(xlsCalendar(t)), Options{Sheet: "Classifica"})
+	assert.ErrorIs(t, err, ErrSheetNotFound)
+}
+
+func TestXLSService_CellTypes(t *testing.T) {
+	xs := NewXLSService()
+
+	data := createXLS(t, nil,
+		[][]byte{
+			biffRec(biffFormat, le16(164), xlUnicodeString("dd/mm/yyyy")),
+			biffRec(biffFormat, le16(165), xlUnicodeString(`0.0" pt"`)),
+			// XFs 16 and 17 use the custom formats.
+			biffRec(biffXF, le16(0, 164), make([]byte, 16)),
+			biffRec(biffXF, le16(0, 165), make([]byte, 16)),
+		},
+		nil,
+		xlsTestSheet{name: "Foglio1", records: [][]byte{
+			xlsNumber(0, 0, 16, 45522),
+			xlsNumber(0, 1, 17, 72.5),
+			biffRec(biffBoolErr, le16(0, 2, 15), []byte{1, 0}),
+			biffRec(biffBoolErr, le16(0, 3, 15), []byte{0x07, 1}),
+			biffRec(biffLabel, le16(0, 4, 15), xlUnicodeString("Città")),
+			// A formula with a cached string value in the STRING record.
+			biffRec(biffFormula, le16(0, 5, 15), []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, le16(0), le32(0), le16(0)),
+			biffRec(biffString, xlUnicodeString("Forlì")),
+			biffRec(biffFormula, le16(0, 6, 15), binary.LittleEndian.AppendUint64(nil, math.Float64bits(61.5)), le16(0), le32(0), le16(0)),
+		}},
+	)
+
+	grid, err := xs.ReadGridFromReader(bytes.NewReader(data), Options{})
+	require.NoError(t, err)
+
+	row := grid.Rows[0]
+	assert.Equal(t, CellTypeDate, row[0].Type)
+	assert.Equal(t, time.Date(2024, 8, 18, 0, 0, 0, 0, time.UTC), row[0].Time)
+	assert.Equal(t, "2024-08-18", row[0].Value)
+	assert.Equal(t, Cell{Ref: "B1", Row: 1, Column: 2, Value: "72.5", Type: CellTypeNumber, Number: 72.5}, row[1])
+	assert.Equal(t, Cell{Ref: "C1", Row: 1, Column: 3, Value: "TRUE", Type: CellTypeBool, Bool: true}, row[2])
+	assert.Equal(t, Cell{Ref: "D1", Row: 1, Column: 4, Value: "#DIV/0!", Type: CellTypeError}, row[3])
+	assert.Equal(t, Cell{Ref: "E1", Row: 1, Column: 5, Value: "Città", Type: CellTypeString}, row[4])
+	assert.Equal(t, Cell{Ref: "F1", Row: 1, Column: 6, Value: "Forlì", Type: CellTypeString}, row[5])
+	assert.Equal(t, Cell{Ref: "G1", Row: 1, Column: 7, Value: "61.5", Type: CellTypeNumber, Number: 61.5}, row[6])
+}
+
+func TestXLSService_TooLarge(t *testing.T) {
+	xs := NewXLSService()
+
+	tests := []struct {
+		name    string
+		records [][]byte
+		err     string
+	}{
+		{
+			name:    "Column past the sheet",
+			records: [][]byte{xlsNumber(0, 65534, 15, 1)},
+			err:     "cell at row 1, column 65535 is past column 256",
+		},
+		{
+			name:    "MulRK running past the sheet",
+			records: [][]byte{biffRec(biffMulRK, le16(0, 255), le16(15), le32(66<<2|0x02), le16(15), le32(66<<2|0x02), le16(256))},
+			err:     "cells up to column 257 are past column 256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := createXLS(t, nil, nil, nil, xlsTestSheet{name: "Foglio1", records: tt.records})
+			_, err := xs.ReadGridFromReader(bytes.NewReader(data), Options{})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	// Cells scattered over many rows, one at the end of each.
+	cells := make(map[[2]int]Cell)
+	for row := 1; row <= 4000; row++ {
+		cells[[2]int{row, 256}] = Cell{Row: row, Column: 256, Value: "1", Type: CellTypeNumber, Number: 1}
+	}
+	err := layOutXLSCells(&Grid{Sheet: "Foglio1"}, cells)
+	assert.EqualError(t, err, "sheet 'Foglio1' has more than 1000000 cells")
+}
+
+func TestXLSService_SharedStringsContinue(t *testing.T) {
+	xs := NewXLSService()
+
+	// "Sampdorietta" is split after "Samp": the CONTINUE record starts with
+	// a flags byte switching the rest of the string to UTF-16.
+	rest := []byte{1}
+	for _, u := range utf16.Encode([]rune("dorietta")) {
+		rest = binary.LittleEndian.AppendUint16(rest, u)
+	}
+	data := createXLSWithSplitString(t, "Giornata 1", "Samp", rest)
+
+	grid, err := xs.ReadGridFromReader(bytes.NewReader(data), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Giornata 1"}, {"Sampdorietta"}}, grid.Values())
+}
+
+// createXLSWithSplitString builds a workbook whose second shared string
+// starts with head in the SST record and ends in a CONTINUE record.
+func createXLSWithSplitString(t *testing.T, first, head string, rest []byte) []byte {
+	t.Helper()
+
+	length := len(head) + (len(rest)-1)/2
+	workbook := createXLS(t, []string{first, head}, nil, biffRec(biffContinue, rest),
+		xlsTestSheet{name: "Foglio1", records: [][]byte{xlsLabelSST(0, 0, 15, 0), xlsLabelSST(1, 0, 15, 1)}},
+	)
+
+	// Patch the length of the second string so that it runs into the
+	// CONTINUE record.
+	needle := xlUnicodeString(head)
+	i := bytes.Index(workbook, needle)
+	require.NotEqual(t, -1, i)
+	binary.LittleEndian.PutUint16(workbook[i:], uint16(length))
+	return workbook
+}
+
+func TestDecodeRK(t *testing.T) {
+	minusThree := int32(-3)
+
+	tests := []struct {
+		name string
+		rk   uint32
+		want float64
+	}{
+		{name: "Integer", rk: 66<<2 | 0x02, want: 66},
+		{name: "Negative integer", rk: uint32(minusThree<<2) | 0x02, want: -3},
+		{name: "Integer divided by 100", rk: 7250<<2 | 0x03, want: 72.5},
+		{name: "Float", rk: uint32(math.Float64bits(72.5) >> 32), want: 72.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decodeRK(tt.rk))
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Detection is the layout DetectLayout settled on, with a confidence from 0
+// to 1 of how well it explains the calendar.
+type Detection struct {
+	Layout     Layout
+	Confidence float64
+}
+
+var markerCandidates = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)giornata`),
+	regexp.MustCompile(`(?i)\bround\b`),
+	regexp.MustCompile(`(?i)\bmatchday\b`),
+	regexp.MustCompile(`(?i)\bturno\b`),
+}
+
+// maxSingleBlockWidth bounds the search for the fields of a calendar with
+// one fixture per row, where there is no second block to measure against.
+const maxSingleBlockWidth = 8
+
+// DetectLayout works out the layout of a calendar from its content: the rows
+// carrying matchday markers, the cells holding a result like "2-1" and the
+// distance between results in the same row. Known layouts are reported by
+// their preset name, anything else as "detected".
+func DetectLayout(calendar [][]string) (Detection, error) {
+	marker, markerRows := detectMarker(calendar)
+
+	resultColumns := make(map[int][]int)
+	totalResults := 0
+	for i, row := range calendar {
+		if markerRows[i] {
+			continue
+		}
+		for column, cell := range row {
+			if resultPattern.MatchString(cell) {
+				resultColumns[i] = append(resultColumns[i], column)
+				totalResults++
+			}
+		}
+	}
+	if totalResults == 0 {
+		return Detection{}, fmt.Errorf("parser: no results like 2-1 found, cannot detect the calendar layout")
+	}
+
+	blocksPerRow, blockWidth := detectBlocks(resultColumns)
+	firstResult := mostCommon(resultColumns, func(columns []int) (int, bool) { return columns[0], true })
+
+	widths := []int{blockWidth}
+	if blocksPerRow == 1 {
+		widths = nil
+		for width := fieldCount; width <= maxSingleBlockWidth; width++ {
+			widths = append(widths, width)
+		}
+	}
+
+	var best *blockFit
+	for _, width := range widths {
+		for resultOffset := width - 1; resultOffset >= 0; resultOffset-- {
+			start := firstResult - resultOffset
+			if start < 0 {
+				continue
+			}
+			fit := fitBlock(calendar, resultColumns, start, width, resultOffset, blocksPerRow)
+			if fit != nil && (best == nil || fit.score > best.score) {
+				best = fit
+			}
+		}
+	}
+	if best == nil {
+		return Detection{}, fmt.Errorf("parser: cannot find two team and two score columns around the results")
+	}
+
+	layout := best.layout(blocksPerRow, marker)
+	layout = matchPreset(layout, calendar, resultColumns)
+
+	explained := 0
+	for _, columns := range resultColumns {
+		for _, column := range columns {
+			relative := column - layout.FirstColumn - layout.Result
+			if relative >= 0 && relative%layout.BlockWidth == 0 && relative/layout.BlockWidth < layout.BlocksPerRow {
+				explained++
+			}
+		}
+	}
+
+	confidence := float64(explained) / float64(totalResults) * best.score
+	if len(markerRows) == 0 {
+		confidence /= 2
+	}
+
+	return Detection{Layout: layout, Confidence: confidence}, nil
+}
+
+// detectMarker picks the marker pattern matching the most rows that hold no
+// result, and returns the rows it matches.
+func detectMarker(calendar [][]string) (*regexp.Regexp, map[int]bool) {
+	best := markerCandidates[0]
+	bestRows := map[int]bool{}
+
+	for _, candidate := range markerCandidates {
+		rows := map[int]bool{}
+		for i, row := range calendar {
+			if hasMarker(row, candidate) {
+				rows[i] = true
+			}
+		}
+		if len(rows) > len(bestRows) {
+			best, bestRows = candidate, rows
+		}
+	}
+
+	return best, bestRows
+}
+
+func hasMarker(row []string, marker *regexp.Regexp) bool {
+	hasMarker := false
+	for _, cell := range row {
+		if resultPattern.MatchString(cell) {
+			return false
+		}
+		if marker.MatchString(cell) {
+			hasMarker = true
+		}
+	}
+	return hasMarker
+}
+
+// detectBlocks returns the number of results in the fullest row, as rows with
+// unplayed fixtures have fewer, and the usual distance between two results
+// of the same row.
+func detectBlocks(resultColumns map[int][]int) (int, int) {
+	blocksPerRow := 0
+	for _, columns := range resultColumns {
+		blocksPerRow = max(blocksPerRow, len(columns))
+	}
+	if blocksPerRow < 2 {
+		return 1, 0
+	}
+
+	blockWidth := mostCommon(resultColumns, func(columns []int) (int, bool) {
+		if len(columns) < 2 {
+			return 0, false
+		}
+		return columns[1] - columns[0], true
+	})
+	return blocksPerRow, blockWidth
+}
+
+// mostCommon returns the most frequent value computed over the rows, the
+// smallest one on ties.
+func mostCommon(rows map[int][]int, value func([]int) (int, bool)) int {
+	counts := make(map[int]int)
+	for _, columns := range rows {
+		if v, ok := value(columns); ok {
+			counts[v]++
+		}
+	}
+
+	best, bestCount := 0, 0
+	for v, count := range counts {
+		if count > bestCount || (count == bestCount && v < best) {
+			best, bestCount = v, count
+		}
+	}
+	return best
+}
+
+// blockFit scores one placement of the blocks: which offsets mostly hold team
+// names and which mostly hold fantasy points.
+type blockFit struct {
+	start        int
+	width        int
+	resultOffset int
+	teams        []int
+	points       []int
+	score        float64
+}
+
+func fitBlock(calendar [][]string, resultColumns map[int][]int, start, width, resultOffset, blocksPerRow int) *blockFit {
+	textual := make([]float64, width)
+	numeric := make([]float64, width)
+	samples := 0
+
+	for i, columns := range resultColumns {
+		row := calendar[i]
+		for b := 0; b < blocksPerRow; b++ {
+			blockStart := start + b*width
+			if !slices.Contains(columns, blockStart+resultOffset) {
+				continue
+			}
+			samples++
+			for offset := 0; offset < width; offset++ {
+				if offset == resultOffset || blockStart+offset >= len(row) {
+					continue
+				}
+				cell := strings.TrimSpace(row[blockStart+offset])
+				if cell == "" {
+					continue
+				}
+				if _, err := parseFantasyPoints(cell); err == nil {
+					numeric[offset]++
+				} else if !resultPattern.MatchString(cell) {
+					textual[offset]++
+				}
+			}
+		}
+	}
+	if samples == 0 {
+		return nil
+	}
+
+	teams := topOffsets(textual, nil, resultOffset)
+	points := topOffsets(numeric, teams, resultOffset)
+	if len(teams) < 2 || len(points) < 2 {
+		return nil
+	}
+
+	score := (textual[teams[0]] + textual[teams[1]] + numeric[points[0]] + numeric[points[1]]) / float64(4*samples)
+	sort.Ints(teams)
+	sort.Ints(points)
+
+	return &blockFit{start: start, width: width, resultOffset: resultOffset, teams: teams, points: points, score: score}
+}
+
+// topOffsets returns the two offsets with the highest non-zero counts, leaving
+// out the result and the ones already taken.
+func topOffsets(counts []float64, taken []int, resultOffset int) []int {
+	var offsets []int
+	for offset, count := range counts {
+		if count > 0 && offset != resultOffset && !slices.Contains(taken, offset) {
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.SliceStable(offsets, func(i, j int) bool {
+		return counts[offsets[i]] > counts[offsets[j]]
+	})
+	if len(offsets) > 2 {
+		offsets = offsets[:2]
+	}
+	return offsets
+}
+
+func (f *blockFit) layout(blocksPerRow int, marker *regexp.Regexp) Layout {
+	return Layout{
+		Name:         "detected",
+		BlocksPerRow: blocksPerRow,
+		BlockWidth:   f.width,
+		FirstColumn:  f.start,
+		HomeTeam:     f.teams[0],
+		HomePoints:   f.points[0],
+		AwayPoints:   f.points[1],
+		AwayTeam:     f.teams[1],
+		Result:       f.resultOffset,
+		Marker:       marker,
+	}
+}
+
+// matchPreset names a detected layout after the preset with the same
+// geometry. The preset row width is kept only when every fixture row has it.
+func matchPreset(layout Layout, calendar [][]string, resultColumns map[int][]int) Layout {
+	for _, preset := range []Layout{DefaultLayout, SingleFixtureLayout} {
+		sameWidth := preset.BlockWidth == layout.BlockWidth || layout.BlocksPerRow == 1
+		if preset.BlocksPerRow != layout.BlocksPerRow || preset.FirstColumn != layout.FirstColumn ||
+			!sameWidth || !slices.Equal(preset.offsets(), layout.offsets()) {
+			continue
+		}
+
+		layout.Name = preset.Name
+		layout.BlockWidth = preset.BlockWidth
+		for i := range resultColumns {
+			if len(calendar[i]) != preset.RowWidth {
+				return layout
+			}
+		}
+		layout.RowWidth = preset.RowWidth
+		return layout
+	}
+	return layout
+}
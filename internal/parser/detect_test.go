@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar [][]string
+		want     Layout
+		wantErr  bool
+	}{
+		{
+			name: "Leghe Export",
+			calendar: [][]string{
+				{"1a Giornata lega", "2a Giornata lega"},
+				{"TeamA", "72.5", "66", "TeamB", "2-1", "TeamA", "60", "61", "TeamC", "0-0"},
+				{"TeamC", "64", "70,5", "TeamD", "0-2", "TeamB", "68", "74", "TeamD", "1-2"},
+			},
+			want: Layout{Name: "leghe", BlocksPerRow: 2, BlockWidth: 5, HomeTeam: 0, HomePoints: 1, AwayPoints: 2, AwayTeam: 3, Result: 4, RowWidth: 10},
+		},
+		{
+			name: "Leghe Export With Empty Cells Kept",
+			calendar: [][]string{
+				{"1a Giornata lega", "", "", "", "", "2a Giornata lega"},
+				{"TeamA", "72.5", "66", "TeamB", "2-1", "TeamA", "60", "61", "TeamC", "0-0"},
+				{"TeamC", "64", "70,5", "TeamD", "0-2", "TeamB", "", "", "TeamD"},
+			},
+			want: Layout{Name: "leghe", BlocksPerRow: 2, BlockWidth: 5, HomeTeam: 0, HomePoints: 1, AwayPoints: 2, AwayTeam: 3, Result: 4},
+		},
+		{
+			name: "Spacer Column Between Blocks",
+			calendar: [][]string{
+				{"Giornata 1", "", "", "", "", "", "Giornata 2"},
+				{"TeamA", "72.5", "66", "TeamB", "2-1", "", "TeamA", "60", "61", "TeamC", "0-0"},
+				{"TeamC", "64", "70,5", "TeamD", "0-2", "", "TeamB", "68", "74", "TeamD", "1-2"},
+			},
+			want: Layout{Name: "detected", BlocksPerRow: 2, BlockWidth: 6, HomeTeam: 0, HomePoints: 1, AwayPoints: 2, AwayTeam: 3, Result: 4},
+		},
+		{
+			name: "One Fixture Per Row",
+			calendar: [][]string{
+				{"Round 1"},
+				{"TeamA", "72.5", "66", "TeamB", "2-1"},
+				{"TeamC", "64", "70,5", "TeamD", "0-2"},
+			},
+			want: Layout{Name: "single", BlocksPerRow: 1, BlockWidth: 5, HomeTeam: 0, HomePoints: 1, AwayPoints: 2, AwayTeam: 3, Result: 4},
+		},
+		{
+			name: "Three Blocks With Result Between Teams",
+			calendar: [][]string{
+				{"", "Turno 1", "", "", "", "", "Turno 2", "", "", "", "", "Turno 3"},
+				{"", "TeamA", "2-1", "TeamB", "72.5", "66", "TeamA", "0-0", "TeamC", "60", "61", "TeamA", "1-2", "TeamD", "66", "70"},
+				{"", "TeamC", "0-2", "TeamD", "64", "70,5", "TeamB", "1-2", "TeamD", "68", "74", "TeamB", "3-0", "TeamC", "80", "62"},
+			},
+			want: Layout{Name: "detected", BlocksPerRow: 3, BlockWidth: 5, FirstColumn: 1, HomeTeam: 0, Result: 1, AwayTeam: 2, HomePoints: 3, AwayPoints: 4},
+		},
+		{
+			name: "No Results",
+			calendar: [][]string{
+				{"Giornata 1"},
+				{"TeamA", "", "", "TeamB", ""},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectLayout(tt.calendar)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			layout := got.Layout
+			layout.Marker = nil
+			if !reflect.DeepEqual(layout, tt.want) {
+				t.Errorf("DetectLayout() layout = %+v, want %+v", layout, tt.want)
+			}
+			if got.Confidence < 0.9 || got.Confidence > 1 {
+				t.Errorf("DetectLayout() confidence = %v, want a confident match", got.Confidence)
+			}
+			if err := got.Layout.Validate(); err != nil {
+				t.Errorf("DetectLayout() returned an invalid layout: %v", err)
+			}
+		})
+	}
+}
+
+func TestDetectLayoutConfidence(t *testing.T) {
+	withMarkers := [][]string{
+		{"Giornata 1"},
+		{"TeamA", "72.5", "66", "TeamB", "2-1"},
+	}
+	withoutMarkers := [][]string{
+		{"TeamA", "72.5", "66", "TeamB", "2-1"},
+	}
+
+	marked, err := DetectLayout(withMarkers)
+	if err != nil {
+		t.Fatalf("DetectLayout() error = %v", err)
+	}
+	unmarked, err := DetectLayout(withoutMarkers)
+	if err != nil {
+		t.Fatalf("DetectLayout() error = %v", err)
+	}
+	if unmarked.Confidence >= marked.Confidence {
+		t.Errorf("confidence without markers = %v, want less than %v", unmarked.Confidence, marked.Confidence)
+	}
+}
+
+func TestDetectingParser(t *testing.T) {
+	parserImpl := NewDetectingParserImpl()
+
+	calendar := [][]string{
+		{"Giornata 1", "", "", "", "", "", "Giornata 2"},
+		{"TeamA", "72.5", "66", "TeamB", "2-1", "", "TeamA", "60", "61", "TeamC", "0-0"},
+	}
+
+	got, report, err := parserImpl.Parse(calendar, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []MatchResults{
+		{Matchday: 1, Label: "Giornata 1", Fixtures: []Fixture{
+			{
+				Home:   TeamResult{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+				Away:   TeamResult{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 66},
+				Result: "2-1", Row: 2, Column: 1, Cell: "A2",
+			},
+		}, TeamResults: []TeamResult{
+			{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+			{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 66},
+		}},
+		{Matchday: 2, Label: "Giornata 2", Fixtures: []Fixture{
+			{
+				Home:   TeamResult{Team: "TeamA", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+				Away:   TeamResult{Team: "TeamC", Goals: 0, Points: 1, FantasyPoints: 61},
+				Result: "0-0", Row: 2, Column: 7, Cell: "G2",
+			},
+		}, TeamResults: []TeamResult{
+			{Team: "TeamA", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+			{Team: "TeamC", Goals: 0, Points: 1, FantasyPoints: 61},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() got = %v, want %v", got, want)
+	}
+	if report.Layout != "detected" || report.Confidence == 0 {
+		t.Errorf("Parse() report layout = %q confidence = %v, want a detected layout", report.Layout, report.Confidence)
+	}
+}
+
+func TestDetectingParserFallsBack(t *testing.T) {
+	parserImpl := NewDetectingParserImpl()
+
+	_, report, err := parserImpl.Parse([][]string{{"Calendario"}}, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if report.Layout != DefaultLayout.Name {
+		t.Errorf("Parse() layout = %q, want %q", report.Layout, DefaultLayout.Name)
+	}
+	if codes := diagnosticCodes(report.Warnings); len(codes) == 0 || codes[0] != CodeLayoutNotDetected {
+		t.Errorf("Parse() warnings = %v, want %s first", codes, CodeLayoutNotDetected)
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// htmlMarkerPattern matches the text of the elements opening a matchday.
+var htmlMarkerPattern = regexp.MustCompile(`(?i)giornata`)
+
+// htmlHeadingTags hold the matchday titles of the page.
+var htmlHeadingTags = map[atom.Atom]bool{
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Caption: true, atom.Header: true, atom.Legend: true,
+}
+
+// htmlSkippedTags never hold calendar text.
+var htmlSkippedTags = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+}
+
+type HTMLParser interface {
+	ParseHTML(reader io.Reader, mode Mode) ([]MatchResults, *ParseReport, error)
+}
+
+// HTMLParserImpl reads calendars from the leghe.fantacalcio.it calendar page
+// saved from the browser. The matchday titles and the fixture table rows are
+// laid out as calendar rows, in page order, and read like a sheet whose
+// layout is detected.
+type HTMLParserImpl struct {
+	parser *ParserImpl
+}
+
+func NewHTMLParserImpl() *HTMLParserImpl {
+	return &HTMLParserImpl{parser: NewDetectingParserImpl()}
+}
+
+// ParseHTML parses a saved calendar page. Diagnostics and fixtures point at
+// the calendar rows read from the page, which have no cell names.
+func (h *HTMLParserImpl) ParseHTML(reader io.Reader, mode Mode) ([]MatchResults, *ParseReport, error) {
+	rows, err := HTMLCalendarRows(reader)
+	if err != nil {
+		return nil, &ParseReport{}, err
+	}
+
+	results, report, err := h.parser.Parse(rows, mode)
+	for _, diagnostics := range [][]Diagnostic{report.Warnings, report.Errors} {
+		for i := range diagnostics {
+			diagnostics[i].Cell = ""
+		}
+	}
+	for _, matchResults := range results {
+		for i := range matchResults.Fixtures {
+			matchResults.Fixtures[i].Cell = ""
+		}
+		for i := range matchResults.Postponed {
+			matchResults.Postponed[i].Cell = ""
+		}
+	}
+	return results, report, err
+}
+
+// HTMLCalendarRows lays out the calendar of a saved page as rows: one row for
+// every matchday title, one for every table row with the text of its cells.
+func HTMLCalendarRows(reader io.Reader) ([][]string, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("parser: reader is nil")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("parser: failed to read HTML page: %w", err)
+	}
+	data, err = decodeHTML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parser: failed to parse HTML page: %w", err)
+	}
+
+	var rows [][]string
+	hasTable := false
+	// Inside a table row only nested tables are looked for, the row text
+	// already holds any title written in its cells.
+	var walk func(n *html.Node, inRow bool)
+	walk = func(n *html.Node, inRow bool) {
+		if n.Type == html.ElementNode {
+			switch {
+			case htmlSkippedTags[n.DataAtom]:
+				return
+			case n.DataAtom == atom.Tr:
+				hasTable, inRow = true, true
+				if row := htmlRow(n); len(row) > 0 {
+					rows = append(rows, row)
+				}
+			case n.DataAtom == atom.Table:
+				inRow = false
+			case !inRow && (htmlHeadingTags[n.DataAtom] || hasMarkerText(n)):
+				if text := htmlText(n); htmlMarkerPattern.MatchString(text) {
+					rows = append(rows, []string{text})
+					return
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, inRow)
+		}
+	}
+	walk(doc, false)
+
+	if !hasTable {
+		return nil, fmt.Errorf("parser: no calendar table found in the HTML page")
+	}
+	return rows, nil
+}
+
+// decodeHTML returns the page as UTF-8, decoding it with the charset its
+// markup declares. Undeclared pages are taken as UTF-8 when they are valid.
+func decodeHTML(data []byte) ([]byte, error) {
+	encoding, name, certain := charset.DetermineEncoding(data, "")
+	if name == "utf-8" || (!certain && name == "windows-1252" && utf8.Valid(data)) {
+		return bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")), nil
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("parser: failed to decode HTML page as %s: %w", name, err)
+	}
+	return decoded, nil
+}
+
+// htmlRow returns the text of the cells of a table row, or nil when they are
+// all empty or the row is a column header such as "Casa | Trasferta". Tables
+// nested in a cell are left to their own rows.
+func htmlRow(tr *html.Node) []string {
+	var row []string
+	hasText, header, marker := false, true, false
+	for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+		if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+			continue
+		}
+		text := htmlText(cell)
+		row = append(row, text)
+		hasText = hasText || text != ""
+		header = header && cell.DataAtom == atom.Th
+		marker = marker || htmlMarkerPattern.MatchString(text)
+	}
+	if !hasText || (header && !marker) {
+		return nil
+	}
+	return row
+}
+
+// hasMarkerText tells whether the element itself, rather than one of its
+// children, carries a matchday title.
+func hasMarkerText(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode && htmlMarkerPattern.MatchString(child.Data) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlText returns the text of an element with its whitespace collapsed,
+// leaving out nested tables and scripts.
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case n.Type == html.ElementNode && (n.DataAtom == atom.Table || htmlSkippedTags[n.DataAtom]):
+			return
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+		if n.Type == html.ElementNode && !htmlInlineTags[n.DataAtom] {
+			b.WriteString(" ")
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// htmlInlineTags do not separate the words around them.
+var htmlInlineTags = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.I: true, atom.Em: true, atom.Strong: true,
+	atom.Sup: true, atom.Sub: true, atom.U: true, atom.Abbr: true, atom.Font: true,
+}
@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const savedCalendarPage = `<!DOCTYPE html>
+<html lang="it">
+<head>
+	<meta charset="utf-8">
+	<title>Calendario - Lega degli Amici</title>
+	<script>var giornata = 3;</script>
+</head>
+<body>
+<nav><a href="/calendario">Calendario</a></nav>
+<main>
+	<div class="card">
+		<div class="card-header"><h4>1ª Giornata lega <small>1ª giornata serie A</small></h4></div>
+		<table class="table">
+			<thead><tr><th>Casa</th><th></th><th></th><th>Trasferta</th><th>Risultato</th></tr></thead>
+			<tbody>
+				<tr>
+					<td><a href="/squadra/1"><span class="team-name">Real Madrink</span></a></td>
+					<td class="fpt">72,5</td>
+					<td class="fpt">66</td>
+					<td><a href="/squadra/2"><span class="team-name">Atletico Ma Non Troppo</span></a></td>
+					<td class="score"><span>2</span> - <span>1</span></td>
+				</tr>
+				<tr>
+					<td>Longobarda</td><td>60</td><td>61,5</td><td>Sampdorietta</td><td>0-0</td>
+				</tr>
+			</tbody>
+		</table>
+	</div>
+	<div class="card">
+		<div class="card-header"><h4>2ª Giornata lega <small>2ª giornata serie A</small></h4></div>
+		<table class="table">
+			<tbody>
+				<tr><td>Atletico Ma Non Troppo</td><td>70</td><td>59</td><td>Longobarda</td><td>1-0</td></tr>
+				<tr><td>Sampdorietta</td><td>77</td><td>80</td><td>Real Madrink</td><td>3-4</td></tr>
+			</tbody>
+		</table>
+	</div>
+</main>
+</body>
+</html>`
+
+func TestHTMLCalendarRows(t *testing.T) {
+	tests := []struct {
+		name        string
+		page        string
+		expected    [][]string
+		expectedErr string
+	}{
+		{
+			name: "Saved calendar page",
+			page: savedCalendarPage,
+			expected: [][]string{
+				{"1ª Giornata lega 1ª giornata serie A"},
+				{"Real Madrink", "72,5", "66", "Atletico Ma Non Troppo", "2 - 1"},
+				{"Longobarda", "60", "61,5", "Sampdorietta", "0-0"},
+				{"2ª Giornata lega 2ª giornata serie A"},
+				{"Atletico Ma Non Troppo", "70", "59", "Longobarda", "1-0"},
+				{"Sampdorietta", "77", "80", "Real Madrink", "3-4"},
+			},
+		},
+		{
+			name: "Title in a table row",
+			page: `<table>
+				<tr><th colspan="5">Giornata 1</th></tr>
+				<tr><td>A</td><td>66</td><td>60</td><td>B</td><td>1-0</td></tr>
+			</table>`,
+			expected: [][]string{
+				{"Giornata 1"},
+				{"A", "66", "60", "B", "1-0"},
+			},
+		},
+		{
+			name: "Nested tables",
+			page: `<table><tr><td>
+				<p>Giornata 1</p>
+				<table><tr><td>A</td><td>66</td><td>60</td><td>B</td><td>1-0</td></tr></table>
+			</td></tr></table>`,
+			expected: [][]string{
+				{"Giornata 1"},
+				{"A", "66", "60", "B", "1-0"},
+			},
+		},
+		{
+			name: "Windows-1252 page",
+			page: "<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=windows-1252\"></head>" +
+				"<body><h3>Giornata 1</h3><table><tr><td>Citt\xe0</td><td>66</td><td>60</td><td>Forl\xec</td><td>1-0</td></tr></table></body></html>",
+			expected: [][]string{
+				{"Giornata 1"},
+				{"Città", "66", "60", "Forlì", "1-0"},
+			},
+		},
+		{
+			name:        "Page without tables",
+			page:        `<html><body><h1>Giornata 1</h1><p>Nessuna partita</p></body></html>`,
+			expectedErr: "parser: no calendar table found in the HTML page",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := HTMLCalendarRows(strings.NewReader(tt.page))
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rows)
+		})
+	}
+}
+
+func TestHTMLParserImpl_ParseHTML(t *testing.T) {
+	p := NewHTMLParserImpl()
+
+	results, report, err := p.ParseHTML(strings.NewReader(savedCalendarPage), ModeLenient)
+	require.NoError(t, err)
+
+	assert.Equal(t, []MatchResults{
+		{
+			Matchday:       1,
+			Label:          "1ª Giornata lega 1ª giornata serie A",
+			SerieAMatchday: 1,
+			Fixtures: []Fixture{
+				{
+					Home:   TeamResult{Team: "Real Madrink", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+					Away:   TeamResult{Team: "Atletico Ma Non Troppo", Goals: 1, Points: 0, FantasyPoints: 66},
+					Result: "2 - 1", Row: 2, Column: 1,
+				},
+				{
+					Home:   TeamResult{Team: "Longobarda", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+					Away:   TeamResult{Team: "Sampdorietta", Goals: 0, Points: 1, FantasyPoints: 61.5},
+					Result: "0-0", Row: 3, Column: 1,
+				},
+			},
+			TeamResults: []TeamResult{
+				{Team: "Real Madrink", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+				{Team: "Atletico Ma Non Troppo", Goals: 1, Points: 0, FantasyPoints: 66},
+				{Team: "Longobarda", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+				{Team: "Sampdorietta", Goals: 0, Points: 1, FantasyPoints: 61.5},
+			},
+		},
+		{
+			Matchday:       2,
+			Label:          "2ª Giornata lega 2ª giornata serie A",
+			SerieAMatchday: 2,
+			Fixtures: []Fixture{
+				{
+					Home:   TeamResult{Team: "Atletico Ma Non Troppo", Home: true, Goals: 1, Points: 3, FantasyPoints: 70},
+					Away:   TeamResult{Team: "Longobarda", Goals: 0, Points: 0, FantasyPoints: 59},
+					Result: "1-0", Row: 5, Column: 1,
+				},
+				{
+					Home:   TeamResult{Team: "Sampdorietta", Home: true, Goals: 3, Points: 0, FantasyPoints: 77},
+					Away:   TeamResult{Team: "Real Madrink", Goals: 4, Points: 3, FantasyPoints: 80},
+					Result: "3-4", Row: 6, Column: 1,
+				},
+			},
+			TeamResults: []TeamResult{
+				{Team: "Atletico Ma Non Troppo", Home: true, Goals: 1, Points: 3, FantasyPoints: 70},
+				{Team: "Longobarda", Goals: 0, Points: 0, FantasyPoints: 59},
+				{Team: "Sampdorietta", Home: true, Goals: 3, Points: 0, FantasyPoints: 77},
+				{Team: "Real Madrink", Goals: 4, Points: 3, FantasyPoints: 80},
+			},
+		},
+	}, results)
+	assert.Equal(t, "single", report.Layout)
+	assert.Empty(t, report.Warnings)
+}
+
+func TestHTMLParserImpl_ParseHTMLStrict(t *testing.T) {
+	p := NewHTMLParserImpl()
+
+	page := strings.Replace(savedCalendarPage, "<td>77</td>", "<td>settantasette</td>", 1)
+	_, report, err := p.ParseHTML(strings.NewReader(page), ModeStrict)
+
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, CodeInvalidFantasyPoints, report.Errors[0].Code)
+	assert.Equal(t, "settantasette", report.Errors[0].Value)
+	assert.Empty(t, report.Errors[0].Cell)
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"fantalegheGO/internal/teams"
+)
+
+// CalendarSchema is the JSON Schema of the calendars ParseJSON accepts, as
+// published by the server.
+//
+//go:embed calendar.schema.json
+var CalendarSchema []byte
+
+var calendarSchema = mustJSONSchema(CalendarSchema)
+
+func mustJSONSchema(data []byte) *jsonSchema {
+	schema, err := newJSONSchema(data)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+type jsonCalendar struct {
+	Matchdays []jsonMatchday `json:"matchdays"`
+}
+
+type jsonMatchday struct {
+	Matchday       int           `json:"matchday"`
+	Label          string        `json:"label"`
+	SerieAMatchday int           `json:"serieAMatchday"`
+	Byes           []string      `json:"byes"`
+	Postponed      []jsonPending `json:"postponed"`
+	Fixtures       []jsonFixture `json:"fixtures"`
+}
+
+type jsonFixture struct {
+	Home jsonSide `json:"home"`
+	Away jsonSide `json:"away"`
+}
+
+type jsonPending struct {
+	Home string `json:"home"`
+	Away string `json:"away"`
+}
+
+type jsonSide struct {
+	Team          string  `json:"team"`
+	FantasyPoints float64 `json:"fantasyPoints"`
+	Goals         int     `json:"goals"`
+}
+
+// ParseJSON reads a calendar sent as structured data instead of a sheet.
+// Fixtures are taken as played, the ones still to be played are listed as
+// postponed. A document not matching CalendarSchema, or listing a matchday
+// twice, fails with a *ParseError whose diagnostics carry the JSON pointer of
+// each offending value.
+func ParseJSON(reader io.Reader) ([]MatchResults, *ParseReport, error) {
+	report := &ParseReport{Layout: "json"}
+	if reader == nil {
+		return nil, report, fmt.Errorf("parser: reader is nil")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, report, fmt.Errorf("parser: failed to read JSON calendar: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, report, fmt.Errorf("parser: invalid JSON calendar: %w", err)
+	}
+	for _, violation := range calendarSchema.validate(document) {
+		report.problem(ModeStrict, violation)
+	}
+	if report.HasErrors() {
+		return nil, report, &ParseError{Report: report}
+	}
+
+	// The schema takes 2.0 as an integer, which would not decode into an int.
+	if data, err = json.Marshal(integralNumbers(document)); err != nil {
+		return nil, report, fmt.Errorf("parser: invalid JSON calendar: %w", err)
+	}
+	var calendar jsonCalendar
+	if err := json.Unmarshal(data, &calendar); err != nil {
+		return nil, report, fmt.Errorf("parser: invalid JSON calendar: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	var results []MatchResults
+	for i, matchday := range calendar.Matchdays {
+		if seen[matchday.Matchday] {
+			report.problem(ModeStrict, Diagnostic{
+				Code:    CodeDuplicateMatchday,
+				Path:    "/matchdays/" + strconv.Itoa(i) + "/matchday",
+				Value:   strconv.Itoa(matchday.Matchday),
+				Message: "matchday is listed more than once",
+			})
+			continue
+		}
+		seen[matchday.Matchday] = true
+
+		matchResults := MatchResults{
+			Matchday:       matchday.Matchday,
+			Label:          matchday.Label,
+			SerieAMatchday: matchday.SerieAMatchday,
+			TeamResults:    []TeamResult{},
+		}
+		for _, fixture := range matchday.Fixtures {
+			home, away := fixture.Home, fixture.Away
+			matchResults.addFixture(Fixture{
+				Home:   TeamResult{Team: teams.Clean(home.Team), Home: true, Goals: home.Goals, Points: calculateMatchPoints(home.Goals, away.Goals), FantasyPoints: home.FantasyPoints},
+				Away:   TeamResult{Team: teams.Clean(away.Team), Goals: away.Goals, Points: calculateMatchPoints(away.Goals, home.Goals), FantasyPoints: away.FantasyPoints},
+				Result: strconv.Itoa(home.Goals) + "-" + strconv.Itoa(away.Goals),
+			})
+		}
+		for _, pending := range matchday.Postponed {
+			matchResults.Postponed = append(matchResults.Postponed, Fixture{
+				Home: TeamResult{Team: teams.Clean(pending.Home), Home: true},
+				Away: TeamResult{Team: teams.Clean(pending.Away)},
+			})
+		}
+		for _, team := range matchday.Byes {
+			matchResults.Byes = append(matchResults.Byes, teams.Clean(team))
+		}
+		if !matchResults.empty() {
+			results = append(results, matchResults)
+		}
+	}
+	if report.HasErrors() {
+		return nil, report, &ParseError{Report: report}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Matchday < results[j].Matchday
+	})
+	return results, report, nil
+}
+
+// integralNumbers returns the decoded document with the numbers that have no
+// fractional part written as integers.
+func integralNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = integralNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = integralNumbers(item)
+		}
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return json.Number(strconv.FormatInt(int64(f), 10))
+		}
+	}
+	return value
+}
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonCalendarDocument = `{
+	"matchdays": [
+		{
+			"matchday": 2,
+			"postponed": [{"home": "Atletico Ma Non Troppo ", "away": "FC Pippo"}],
+			"fixtures": [
+				{"home": {"team": "Longobarda", "fantasyPoints": 59, "goals": 0}, "away": {"team": "Real Madrink", "fantasyPoints": 70.5, "goals": 1}}
+			]
+		},
+		{
+			"matchday": 1,
+			"label": "1ª Giornata lega",
+			"serieAMatchday": 3,
+			"fixtures": [
+				{"home": {"team": "Real Madrink", "fantasyPoints": 66, "goals": 1}, "away": {"team": "Longobarda", "fantasyPoints": 66.5, "goals": 1}}
+			]
+		},
+		{"matchday": 3, "byes": ["Real Madrink"], "fixtures": []}
+	]
+}`
+
+func TestParseJSON(t *testing.T) {
+	results, report, err := ParseJSON(strings.NewReader(jsonCalendarDocument))
+	require.NoError(t, err)
+
+	assert.Equal(t, []MatchResults{
+		{
+			Matchday:       1,
+			Label:          "1ª Giornata lega",
+			SerieAMatchday: 3,
+			Fixtures: []Fixture{
+				{
+					Home:   TeamResult{Team: "Real Madrink", Home: true, Goals: 1, Points: 1, FantasyPoints: 66},
+					Away:   TeamResult{Team: "Longobarda", Goals: 1, Points: 1, FantasyPoints: 66.5},
+					Result: "1-1",
+				},
+			},
+			TeamResults: []TeamResult{
+				{Team: "Real Madrink", Home: true, Goals: 1, Points: 1, FantasyPoints: 66},
+				{Team: "Longobarda", Goals: 1, Points: 1, FantasyPoints: 66.5},
+			},
+		},
+		{
+			Matchday: 2,
+			Fixtures: []Fixture{
+				{
+					Home:   TeamResult{Team: "Longobarda", Home: true, Goals: 0, Points: 0, FantasyPoints: 59},
+					Away:   TeamResult{Team: "Real Madrink", Goals: 1, Points: 3, FantasyPoints: 70.5},
+					Result: "0-1",
+				},
+			},
+			TeamResults: []TeamResult{
+				{Team: "Longobarda", Home: true, Goals: 0, Points: 0, FantasyPoints: 59},
+				{Team: "Real Madrink", Goals: 1, Points: 3, FantasyPoints: 70.5},
+			},
+			Postponed: []Fixture{
+				{Home: TeamResult{Team: "Atletico Ma Non Troppo", Home: true}, Away: TeamResult{Team: "FC Pippo"}},
+			},
+		},
+		{
+			Matchday:    3,
+			TeamResults: []TeamResult{},
+			Byes:        []string{"Real Madrink"},
+		},
+	}, results)
+	assert.Equal(t, "json", report.Layout)
+	assert.Empty(t, report.Warnings)
+}
+
+func TestParseJSON_IntegralNumbers(t *testing.T) {
+	document := `{"matchdays": [{"matchday": 1.0, "fixtures": [
+		{"home": {"team": "A", "fantasyPoints": 72.0, "goals": 2.0}, "away": {"team": "B", "fantasyPoints": 66.5, "goals": 1e0}}
+	]}]}`
+
+	results, _, err := ParseJSON(strings.NewReader(document))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 1, results[0].Matchday)
+	assert.Equal(t, "2-1", results[0].Fixtures[0].Result)
+	assert.Equal(t, 72.0, results[0].Fixtures[0].Home.FantasyPoints)
+}
+
+func TestParseJSON_Invalid(t *testing.T) {
+	side := `{"team": "A", "fantasyPoints": 66, "goals": 1}`
+	tests := []struct {
+		name     string
+		document string
+		expected []Diagnostic
+	}{
+		{
+			name:     "No matchdays",
+			document: `{"matchdays": []}`,
+			expected: []Diagnostic{
+				{Code: CodeSchemaViolation, Path: "/matchdays", Value: "[]", Message: "must have at least 1 items"},
+			},
+		},
+		{
+			name:     "Missing goals and negative fantasy points",
+			document: `{"matchdays": [{"matchday": 1, "fixtures": [{"home": {"team": "A", "fantasyPoints": -1}, "away": ` + side + `}]}]}`,
+			expected: []Diagnostic{
+				{Code: CodeSchemaViolation, Path: "/matchdays/0/fixtures/0/home", Value: `{"fantasyPoints":-1,"team":"A"}`, Message: `missing required property "goals"`},
+				{Code: CodeSchemaViolation, Path: "/matchdays/0/fixtures/0/home/fantasyPoints", Value: "-1", Message: "must be at least 0"},
+			},
+		},
+		{
+			name:     "Wrong types",
+			document: `{"matchdays": [{"matchday": "1", "fixtures": [{"home": {"team": "A", "fantasyPoints": 66, "goals": 1.5}, "away": ` + side + `}]}]}`,
+			expected: []Diagnostic{
+				{Code: CodeSchemaViolation, Path: "/matchdays/0/fixtures/0/home/goals", Value: "1.5", Message: "must be integer"},
+				{Code: CodeSchemaViolation, Path: "/matchdays/0/matchday", Value: "1", Message: "must be integer"},
+			},
+		},
+		{
+			name:     "Unknown property",
+			document: `{"matchdays": [{"matchday": 1, "round/name": "x", "fixtures": []}]}`,
+			expected: []Diagnostic{
+				{Code: CodeSchemaViolation, Path: "/matchdays/0/round~1name", Value: "x", Message: `unknown property "round/name"`},
+			},
+		},
+		{
+			name:     "Duplicate matchday",
+			document: `{"matchdays": [{"matchday": 1, "fixtures": []}, {"matchday": 1, "fixtures": []}]}`,
+			expected: []Diagnostic{
+				{Code: CodeDuplicateMatchday, Path: "/matchdays/1/matchday", Value: "1", Message: "matchday is listed more than once"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, report, err := ParseJSON(strings.NewReader(tt.document))
+
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr), "expected a *ParseError, got %v", err)
+			for i := range tt.expected {
+				tt.expected[i].Severity = SeverityError
+			}
+			assert.Equal(t, tt.expected, report.Errors)
+		})
+	}
+}
+
+func TestParseJSON_Malformed(t *testing.T) {
+	_, _, err := ParseJSON(strings.NewReader(`{"matchdays": [`))
+
+	var parseErr *ParseError
+	assert.False(t, errors.As(err, &parseErr))
+	assert.ErrorContains(t, err, "parser: invalid JSON calendar")
+}
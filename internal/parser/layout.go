@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Layout describes where fixtures sit in a calendar sheet. A row holds
+// BlocksPerRow fixture blocks, each BlockWidth columns wide, and every block
+// keeps its fields at the same offsets.
+type Layout struct {
+	Name string
+	// BlocksPerRow is how many fixtures are laid side by side in a row.
+	BlocksPerRow int
+	// BlockWidth is the distance in columns between the starts of two blocks.
+	BlockWidth int
+	// FirstColumn is the 0-based column the first block starts at.
+	FirstColumn int
+	// Offsets of each fixture field from the start of its block.
+	HomeTeam   int
+	HomePoints int
+	AwayPoints int
+	AwayTeam   int
+	Result     int
+	// Marker matches the cell that opens a new matchday.
+	Marker *regexp.Regexp
+	// RowWidth, when set, is the exact number of cells a fixture row must
+	// have. Zero accepts rows of any width and ignores empty blocks.
+	RowWidth int
+}
+
+// DefaultLayout is the leghe.fantacalcio.it export: two fixtures side by side
+// in 10 columns, each laid out as team, score, score, team, result.
+var DefaultLayout = Layout{
+	Name:         "leghe",
+	BlocksPerRow: 2,
+	BlockWidth:   5,
+	HomeTeam:     0,
+	HomePoints:   1,
+	AwayPoints:   2,
+	AwayTeam:     3,
+	Result:       4,
+	Marker:       regexp.MustCompile(`Giornata`),
+	RowWidth:     10,
+}
+
+// SingleFixtureLayout has one team, score, score, team, result fixture per
+// row, as found in most hand-made sheets.
+var SingleFixtureLayout = Layout{
+	Name:         "single",
+	BlocksPerRow: 1,
+	BlockWidth:   5,
+	HomeTeam:     0,
+	HomePoints:   1,
+	AwayPoints:   2,
+	AwayTeam:     3,
+	Result:       4,
+	Marker:       regexp.MustCompile(`(?i)giornata`),
+}
+
+// Field indexes of the normalized block handed to getTeamResult.
+const (
+	fieldHomeTeam = iota
+	fieldHomePoints
+	fieldAwayPoints
+	fieldAwayTeam
+	fieldResult
+	fieldCount
+)
+
+// withSpacer returns the layout moved past an empty spacer column, before the
+// first block or between the blocks, when the fixture rows of the calendar
+// are too wide for RowWidth because of it. Spreadsheet exports often keep such
+// a blank column, which a row reader dropping empty cells never saw.
+func (l Layout) withSpacer(calendar [][]string) (Layout, bool) {
+	if l.RowWidth == 0 {
+		return l, false
+	}
+	leading := l
+	leading.FirstColumn++
+	leading.RowWidth++
+	type candidate struct {
+		layout  Layout
+		spacers []int
+	}
+	candidates := []candidate{{leading, []int{l.FirstColumn}}}
+	if l.BlocksPerRow > 1 {
+		between := l
+		between.BlockWidth++
+		between.RowWidth += l.BlocksPerRow - 1
+		var spacers []int
+		for b := 1; b < l.BlocksPerRow; b++ {
+			spacers = append(spacers, between.blockStart(b)-1)
+		}
+		candidates = append(candidates, candidate{between, spacers})
+	}
+
+	for _, c := range candidates {
+		fits := false
+		for _, row := range calendar {
+			if !hasResult(row) {
+				continue
+			}
+			if len(row) != c.layout.RowWidth {
+				fits = false
+				break
+			}
+			fits = true
+			for _, spacer := range c.spacers {
+				if strings.TrimSpace(row[spacer]) != "" {
+					fits = false
+				}
+			}
+			if !fits {
+				break
+			}
+		}
+		if fits {
+			return c.layout, true
+		}
+	}
+	return l, false
+}
+
+// hasResult tells whether a row holds a fixture result.
+func hasResult(row []string) bool {
+	for _, cell := range row {
+		if resultPattern.MatchString(cell) {
+			return true
+		}
+	}
+	return false
+}
+
+// offsets returns the field offsets in normalized block order.
+func (l Layout) offsets() []int {
+	return []int{l.HomeTeam, l.HomePoints, l.AwayPoints, l.AwayTeam, l.Result}
+}
+
+// blockStart returns the 0-based column block b starts at.
+func (l Layout) blockStart(b int) int {
+	return l.FirstColumn + b*l.BlockWidth
+}
+
+func (l Layout) Validate() error {
+	if l.BlocksPerRow < 1 {
+		return fmt.Errorf("parser: layout %q needs at least one block per row", l.Name)
+	}
+	if l.FirstColumn < 0 {
+		return fmt.Errorf("parser: layout %q has a negative first column", l.Name)
+	}
+	if l.Marker == nil {
+		return fmt.Errorf("parser: layout %q has no matchday marker", l.Name)
+	}
+
+	seen := make(map[int]bool)
+	for _, offset := range l.offsets() {
+		if offset < 0 || offset >= l.BlockWidth {
+			return fmt.Errorf("parser: layout %q has offset %d outside its %d columns block", l.Name, offset, l.BlockWidth)
+		}
+		if seen[offset] {
+			return fmt.Errorf("parser: layout %q uses offset %d twice", l.Name, offset)
+		}
+		seen[offset] = true
+	}
+
+	if l.RowWidth != 0 && l.RowWidth < l.blockStart(l.BlocksPerRow) {
+		return fmt.Errorf("parser: layout %q rows of %d cells cannot hold %d blocks", l.Name, l.RowWidth, l.BlocksPerRow)
+	}
+	return nil
+}
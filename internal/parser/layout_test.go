@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestLayoutValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  func() Layout
+		wantErr bool
+	}{
+		{
+			name:   "Default Layout",
+			layout: func() Layout { return DefaultLayout },
+		},
+		{
+			name:   "Single Fixture Layout",
+			layout: func() Layout { return SingleFixtureLayout },
+		},
+		{
+			name: "No Blocks",
+			layout: func() Layout {
+				l := DefaultLayout
+				l.BlocksPerRow = 0
+				return l
+			},
+			wantErr: true,
+		},
+		{
+			name: "Offset Outside Block",
+			layout: func() Layout {
+				l := DefaultLayout
+				l.Result = 5
+				return l
+			},
+			wantErr: true,
+		},
+		{
+			name: "Duplicated Offset",
+			layout: func() Layout {
+				l := DefaultLayout
+				l.AwayTeam = l.HomeTeam
+				return l
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing Marker",
+			layout: func() Layout {
+				l := DefaultLayout
+				l.Marker = nil
+				return l
+			},
+			wantErr: true,
+		},
+		{
+			name: "Row Width Too Small",
+			layout: func() Layout {
+				l := DefaultLayout
+				l.RowWidth = 8
+				return l
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.layout().Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseWithLayout(t *testing.T) {
+	threeBlocks := Layout{
+		Name:         "three",
+		BlocksPerRow: 3,
+		BlockWidth:   6,
+		FirstColumn:  1,
+		HomeTeam:     0,
+		AwayTeam:     1,
+		Result:       2,
+		HomePoints:   3,
+		AwayPoints:   4,
+		Marker:       regexp.MustCompile(`(?i)round`),
+	}
+
+	tests := []struct {
+		name     string
+		layout   Layout
+		calendar [][]string
+		want     []MatchResults
+	}{
+		{
+			name:   "One Fixture Per Row",
+			layout: SingleFixtureLayout,
+			calendar: [][]string{
+				{"Calendario"},
+				{"1a giornata"},
+				{"TeamA", "70", "64", "TeamB", "2-0"},
+				{"TeamC", "66", "66,5", "TeamD", "1-1"},
+				{"2a giornata"},
+				{"TeamA", "59", "72", "TeamC", "0-2"},
+			},
+			want: []MatchResults{
+				{Matchday: 1, Label: "1a giornata", Fixtures: []Fixture{
+					{
+						Home:   TeamResult{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 70},
+						Away:   TeamResult{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 64},
+						Result: "2-0", Row: 3, Column: 1, Cell: "A3",
+					},
+					{
+						Home:   TeamResult{Team: "TeamC", Home: true, Goals: 1, Points: 1, FantasyPoints: 66},
+						Away:   TeamResult{Team: "TeamD", Goals: 1, Points: 1, FantasyPoints: 66.5},
+						Result: "1-1", Row: 4, Column: 1, Cell: "A4",
+					},
+				}, TeamResults: []TeamResult{
+					{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 70},
+					{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 64},
+					{Team: "TeamC", Home: true, Goals: 1, Points: 1, FantasyPoints: 66},
+					{Team: "TeamD", Goals: 1, Points: 1, FantasyPoints: 66.5},
+				}},
+				{Matchday: 2, Label: "2a giornata", Fixtures: []Fixture{
+					{
+						Home:   TeamResult{Team: "TeamA", Home: true, Goals: 0, Points: 0, FantasyPoints: 59},
+						Away:   TeamResult{Team: "TeamC", Goals: 2, Points: 3, FantasyPoints: 72},
+						Result: "0-2", Row: 6, Column: 1, Cell: "A6",
+					},
+				}, TeamResults: []TeamResult{
+					{Team: "TeamA", Home: true, Goals: 0, Points: 0, FantasyPoints: 59},
+					{Team: "TeamC", Goals: 2, Points: 3, FantasyPoints: 72},
+				}},
+			},
+		},
+		{
+			name:   "Three Blocks With Another Column Order",
+			layout: threeBlocks,
+			calendar: [][]string{
+				{"", "Round 1", "", "", "", "", "", "Round 2", "", "", "", "", "", "Round 3"},
+				{"", "TeamA", "TeamB", "1-0", "68", "63", "", "TeamA", "TeamC", "0-0", "60", "61", "", "TeamA", "TeamD", "", "", ""},
+			},
+			want: []MatchResults{
+				{Matchday: 1, Label: "Round 1", Fixtures: []Fixture{
+					{
+						Home:   TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 68},
+						Away:   TeamResult{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 63},
+						Result: "1-0", Row: 2, Column: 2, Cell: "B2",
+					},
+				}, TeamResults: []TeamResult{
+					{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 68},
+					{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 63},
+				}},
+				{Matchday: 2, Label: "Round 2", Fixtures: []Fixture{
+					{
+						Home:   TeamResult{Team: "TeamA", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+						Away:   TeamResult{Team: "TeamC", Goals: 0, Points: 1, FantasyPoints: 61},
+						Result: "0-0", Row: 2, Column: 8, Cell: "H2",
+					},
+				}, TeamResults: []TeamResult{
+					{Team: "TeamA", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+					{Team: "TeamC", Goals: 0, Points: 1, FantasyPoints: 61},
+				}},
+				{Matchday: 3, Label: "Round 3", TeamResults: []TeamResult{}, Postponed: []Fixture{
+					{Home: TeamResult{Team: "TeamA", Home: true}, Away: TeamResult{Team: "TeamD"}, Row: 2, Column: 14, Cell: "N2"},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parserImpl, err := NewParserImplWithLayout(tt.layout)
+			if err != nil {
+				t.Fatalf("NewParserImplWithLayout() error = %v", err)
+			}
+
+			got, _, err := parserImpl.Parse(tt.calendar, ModeStrict)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewParserImplWithInvalidLayout(t *testing.T) {
+	layout := DefaultLayout
+	layout.BlocksPerRow = 0
+
+	if _, err := NewParserImplWithLayout(layout); err == nil {
+		t.Error("NewParserImplWithLayout() error = nil, want an error")
+	}
+}
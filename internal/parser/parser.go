@@ -1,15 +1,70 @@
 package parser
 
 type MatchResults struct {
+	// Matchday is the league round number, e.g. 3 for "3a Giornata lega".
+	Matchday int
+	// Label is the raw marker text the round was read from.
+	Label string
+	// SerieAMatchday is the Serie A round the league round was played on,
+	// or 0 when the export does not say.
+	SerieAMatchday int
+	// Fixtures are the played matches of the round, in calendar order.
+	Fixtures []Fixture
+	// TeamResults are the teams of Fixtures, home then away for each fixture.
 	TeamResults []TeamResult
+	// Byes are the teams resting in the round, as leagues with an odd number
+	// of teams have one every round.
+	Byes []string
+	// Postponed are the fixtures of the round without a result yet, e.g.
+	// because their Serie A match was postponed. Only the team names of their
+	// sides are set.
+	Postponed []Fixture
+}
+
+// Incomplete tells whether the round was played in part only: some fixtures
+// have a result and some are still to be played. A round none of whose
+// fixtures has a result is not played yet rather than incomplete.
+func (m MatchResults) Incomplete() bool {
+	return len(m.Fixtures) > 0 && len(m.Postponed) > 0
+}
+
+// empty tells whether the round has nothing to rank: no team played, rested
+// or is waiting for a postponed fixture.
+func (m MatchResults) empty() bool {
+	return len(m.TeamResults) == 0 && len(m.Postponed) == 0 && len(m.Byes) == 0
+}
+
+// Fixture is a played match: who met whom, at home or away, and how it went.
+type Fixture struct {
+	Home TeamResult
+	Away TeamResult
+	// Result is the result as written in the calendar, e.g. "2-1".
+	Result string
+	// Row and Column are the 1-based position of the fixture block in the
+	// calendar grid and Cell is its sheet cell, e.g. "F3". They are unset when
+	// the calendar has no grid, as for JSON calendars.
+	Row    int
+	Column int
+	Cell   string
+}
+
+// addFixture records a played fixture along with the results of its teams.
+func (m *MatchResults) addFixture(fixture Fixture) {
+	m.Fixtures = append(m.Fixtures, fixture)
+	m.TeamResults = append(m.TeamResults, fixture.Home, fixture.Away)
 }
 
 type TeamResult struct {
-	Team   string
-	Goals  int
-	Points int
+	Team string
+	// Home is set on the team playing at home. The fantasy total of the home
+	// team includes the home bonus of the league, if any.
+	Home          bool
+	Goals         int
+	Points        int
+	FantasyPoints float64
 }
 
 type Parser interface {
 	GetTeamResults(calendar [][]string) ([]MatchResults, error)
+	Parse(calendar [][]string, mode Mode) ([]MatchResults, *ParseReport, error)
 }
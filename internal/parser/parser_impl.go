@@ -1,98 +1,450 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+
+	"fantalegheGO/internal/scoring"
+	"fantalegheGO/internal/teams"
 )
 
-type ParserImpl struct{}
+// markerWords are the words of the round markers DetectLayout accepts.
+const markerWords = `(?:giornata|\bround\b|\bmatchday\b|\bturno\b)`
+
+var (
+	matchdayPattern       = regexp.MustCompile(`(?i)(\d+)\s*(?:[aª°]|st|nd|rd|th)?\s*` + markerWords + `|` + markerWords + `\s*(?:lega\s*)?(?:n\.?\s*)?(\d+)`)
+	serieAMatchdayPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:[aª°]|st|nd|rd|th)?\s*` + markerWords + `\s+(?:di\s+)?serie\s*a|serie\s*a\D*(\d+)`)
+	resultPattern         = regexp.MustCompile(`^\s*\d+\s*-\s*\d+\s*$`)
+	byePattern            = regexp.MustCompile(`(?i)^\s*(riposo|riposa|bye)\s*$`)
+)
 
+type ParserImpl struct {
+	layout Layout
+	detect bool
+}
+
+// NewParserImpl returns a parser for the leghe.fantacalcio.it export.
 func NewParserImpl() *ParserImpl {
-	return &ParserImpl{}
+	return &ParserImpl{layout: DefaultLayout}
+}
+
+// NewParserImplWithLayout returns a parser reading calendars laid out as
+// described by layout.
+func NewParserImplWithLayout(layout Layout) (*ParserImpl, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	return &ParserImpl{layout: layout}, nil
+}
+
+// NewDetectingParserImpl returns a parser that works out the layout of each
+// calendar, falling back to the leghe.fantacalcio.it export when it cannot.
+func NewDetectingParserImpl() *ParserImpl {
+	return &ParserImpl{layout: DefaultLayout, detect: true}
+}
+
+// calendarRow is either a matchday marker or one fixture block of a sheet row,
+// normalized to team, score, score, team, result. It keeps the position it
+// was read from so diagnostics can point back at the sheet.
+type calendarRow struct {
+	row    int
+	column int
+	marker bool
+	// ordinal numbers a marker in reading order, row by row and left to
+	// right across the blocks. It numbers the rounds whose marker has none.
+	ordinal int
+	// bye marks a team resting in the round, named by the only cell.
+	bye   bool
+	cells []string
+}
+
+// cellError describes an unusable cell of a fixture block.
+type cellError struct {
+	field  int
+	code   string
+	value  string
+	reason string
+}
+
+func (e *cellError) Error() string {
+	return fmt.Sprintf("%s %q: %s", e.code, e.value, e.reason)
 }
 
+// GetTeamResults parses the calendar in strict mode: the whole calendar is
+// read and every invalid fixture is returned in a *ParseError.
 func (p *ParserImpl) GetTeamResults(calendar [][]string) ([]MatchResults, error) {
-	var teamResults []TeamResult
+	results, _, err := p.Parse(calendar, ModeStrict)
+	return results, err
+}
+
+// Parse reads the calendar and reports every row or value it had to skip. In
+// strict mode invalid data makes it return a *ParseError.
+func (p *ParserImpl) Parse(calendar [][]string, mode Mode) ([]MatchResults, *ParseReport, error) {
+	report := &ParseReport{}
+	layout := p.layout
+	if p.detect && len(calendar) > 0 {
+		detection, err := DetectLayout(calendar)
+		if err != nil {
+			report.warn(Diagnostic{Code: CodeLayoutNotDetected, Message: err.Error()})
+		} else {
+			layout = detection.Layout
+			report.Confidence = detection.Confidence
+		}
+	} else if spaced, ok := layout.withSpacer(calendar); ok {
+		layout = spaced
+	}
+	report.Layout = layout.Name
+
+	var current MatchResults
 	var results []MatchResults
 
-	for _, calendarRow := range splitRows(calendar) {
-		if len(calendarRow) > 0 && strings.Contains(calendarRow[0], "Giornata") {
-			if len(teamResults) > 0 {
-				results = append(results, MatchResults{TeamResults: teamResults})
+	for _, calendarRow := range splitRows(calendar, layout, report, mode) {
+		cells := calendarRow.cells
+		if calendarRow.marker {
+			if !current.empty() {
+				results = append(results, current)
+			}
+			current = newMatchResults(cells[0], calendarRow.ordinal)
+			continue
+		}
+		if calendarRow.bye {
+			current.Byes = append(current.Byes, teams.Clean(cells[0]))
+			continue
+		}
+		matchResults, err := getTeamResult(cells)
+		if err != nil {
+			var cellErr *cellError
+			if errors.As(err, &cellErr) {
+				report.problem(mode, Diagnostic{
+					Row:     calendarRow.row,
+					Column:  calendarRow.column + layout.offsets()[cellErr.field],
+					Code:    cellErr.code,
+					Value:   cellErr.value,
+					Message: cellErr.reason,
+				})
 			}
-			teamResults = []TeamResult{}
 			continue
 		}
-		teamResults = append(teamResults, getTeamResult(calendarRow)...)
+		fixture := Fixture{
+			Row:    calendarRow.row,
+			Column: calendarRow.column,
+			Cell:   cellName(calendarRow.column, calendarRow.row),
+		}
+		if len(matchResults) == 2 {
+			fixture.Home, fixture.Away = matchResults[0], matchResults[1]
+			fixture.Result = strings.TrimSpace(cells[fieldResult])
+			current.addFixture(fixture)
+		} else if home, away, ok := unplayedTeams(cells); ok {
+			fixture.Home = TeamResult{Team: home, Home: true}
+			fixture.Away = TeamResult{Team: away}
+			current.Postponed = append(current.Postponed, fixture)
+		}
+	}
+
+	if !current.empty() {
+		results = append(results, current)
 	}
 
-	if len(teamResults) > 0 {
-		results = append(results, MatchResults{TeamResults: teamResults})
+	if report.HasErrors() {
+		return nil, report, &ParseError{Report: report}
 	}
 
-	return results, nil
+	// Side by side blocks are read one column at a time, so rounds come out
+	// as 1, 3, 5, ..., 2, 4, 6: restore the calendar order.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Matchday < results[j].Matchday
+	})
+
+	return results, report, nil
 }
 
-func getTeamResult(match []string) []TeamResult {
-	if len(match) < 5 {
-		return []TeamResult{}
+// newMatchResults builds an empty round from a marker such as
+// "3a Giornata lega (5a giornata serie A)", "Giornata lega 12 (Serie A 14)" or
+// "Round 3". When the label carries no number the round is numbered by the
+// reading order of its marker.
+func newMatchResults(label string, fallback int) MatchResults {
+	matchResults := MatchResults{Matchday: fallback, Label: label, TeamResults: []TeamResult{}}
+
+	leagueLabel := label
+	if loc := serieAMatchdayPattern.FindStringSubmatchIndex(label); loc != nil {
+		matchResults.SerieAMatchday = firstNumber(label, loc)
+		leagueLabel = label[:loc[0]] + label[loc[1]:]
 	}
+	if loc := matchdayPattern.FindStringSubmatchIndex(leagueLabel); loc != nil {
+		matchResults.Matchday = firstNumber(leagueLabel, loc)
+	}
+
+	return matchResults
+}
 
-	goalsStr := strings.Split(match[4], "-")
-	if len(goalsStr) != 2 {
-		return []TeamResult{}
+// firstNumber returns the first matched capture group of a submatch index as
+// an integer.
+func firstNumber(s string, loc []int) int {
+	for i := 2; i+1 < len(loc); i += 2 {
+		if loc[i] >= 0 {
+			n, _ := strconv.Atoi(s[loc[i]:loc[i+1]])
+			return n
+		}
 	}
+	return 0
+}
 
-	if len(goalsStr[0]) == 0 || len(goalsStr[1]) == 0 {
-		return []TeamResult{}
+// getTeamResult reads a fixture block laid out as team, fantasy points,
+// fantasy points, team, result. A block without a result has not been played
+// yet and yields no results.
+func getTeamResult(match []string) ([]TeamResult, error) {
+	if len(match) < 5 {
+		return []TeamResult{}, nil
 	}
 
-	goalA, errA := strconv.Atoi(goalsStr[0])
-	goalB, errB := strconv.Atoi(goalsStr[1])
+	result := strings.TrimSpace(match[4])
+	if result == "" || result == "-" {
+		return []TeamResult{}, nil
+	}
+
+	goalsStr := strings.Split(result, "-")
+	if len(goalsStr) != 2 || len(goalsStr[0]) == 0 || len(goalsStr[1]) == 0 {
+		return nil, &cellError{field: fieldResult, code: CodeInvalidResult, value: match[4], reason: "expected a result like 2-1"}
+	}
 
+	goalA, errA := strconv.Atoi(strings.TrimSpace(goalsStr[0]))
+	goalB, errB := strconv.Atoi(strings.TrimSpace(goalsStr[1]))
 	if errA != nil || errB != nil {
-		fmt.Printf("Error parsing goals: %v, %v\n", errA, errB)
-		return []TeamResult{}
+		return nil, &cellError{field: fieldResult, code: CodeInvalidResult, value: match[4], reason: "goals are not numbers"}
 	}
 
-	teamA := match[0]
-	teamB := match[3]
+	teamA := teams.Clean(match[0])
+	teamB := teams.Clean(match[3])
+
+	fantasyA, err := parseFantasyPoints(match[1])
+	if err != nil {
+		return nil, &cellError{field: fieldHomePoints, code: CodeInvalidFantasyPoints, value: match[1], reason: fmt.Sprintf("fantasy points of %s: %v", teamA, err)}
+	}
+	fantasyB, err := parseFantasyPoints(match[2])
+	if err != nil {
+		return nil, &cellError{field: fieldAwayPoints, code: CodeInvalidFantasyPoints, value: match[2], reason: fmt.Sprintf("fantasy points of %s: %v", teamB, err)}
+	}
 
 	return []TeamResult{
-		{Team: teamA, Goals: goalA, Points: calculateMatchPoints(goalA, goalB)},
-		{Team: teamB, Goals: goalB, Points: calculateMatchPoints(goalB, goalA)},
+		{Team: teamA, Home: true, Goals: goalA, Points: calculateMatchPoints(goalA, goalB), FantasyPoints: fantasyA},
+		{Team: teamB, Goals: goalB, Points: calculateMatchPoints(goalB, goalA), FantasyPoints: fantasyB},
+	}, nil
+}
+
+// unplayedTeams returns the teams of a fixture block without a result, when
+// it names two of them.
+func unplayedTeams(match []string) (string, string, bool) {
+	if len(match) < fieldCount {
+		return "", "", false
+	}
+	home, away := teams.Clean(match[fieldHomeTeam]), teams.Clean(match[fieldAwayTeam])
+	if home == "" || away == "" || home == "-" || away == "-" {
+		return "", "", false
 	}
+	return home, away, true
 }
 
-func calculateMatchPoints(ourGoals, theirGoals int) int {
-	if ourGoals > theirGoals {
-		return 3
-	} else if ourGoals < theirGoals {
-		return 0
+// parseFantasyPoints accepts both "72.5" and the Italian "72,5" notation.
+func parseFantasyPoints(value string) (float64, error) {
+	normalized := strings.Replace(strings.TrimSpace(value), ",", ".", 1)
+	points, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %q as a number", value)
 	}
-	return 1
+	return points, nil
 }
 
-func splitRows(rows [][]string) [][]string {
-	var firstHalves [][]string
-	var secondHalves [][]string
-	var result [][]string
+// calculateMatchPoints scores a match with the default rules; callers with
+// other rules rescore the results, see scoring.ScoringRules.
+func calculateMatchPoints(ourGoals, theirGoals int) int {
+	return scoring.Default().Points(ourGoals, theirGoals)
+}
 
-	for _, innerList := range rows {
-		if len(innerList) != 10 {
+// splitRows cuts every row into the fixture blocks of the layout, returning
+// all the blocks of the first column first, then all the ones of the second
+// and so on, each stream led by its matchday markers. Rows that do not fit
+// the layout are reported and skipped.
+func splitRows(rows [][]string, layout Layout, report *ParseReport, mode Mode) []calendarRow {
+	blocks := make([][]calendarRow, layout.BlocksPerRow)
+	var result []calendarRow
+	known := calendarTeams(rows, layout)
+	ordinal := 0
+
+	for i, innerList := range rows {
+		if markers := splitMarkers(i+1, innerList, layout); len(markers) > 0 {
+			for b, marker := range markers {
+				if marker.marker {
+					ordinal++
+					marker.ordinal = ordinal
+					blocks[b] = append(blocks[b], marker)
+				}
+			}
 			continue
 		}
-		midpoint := len(innerList) / 2
-		firstHalf := innerList[:midpoint]
-		secondHalf := innerList[midpoint:]
 
-		firstHalves = append(firstHalves, firstHalf)
-		secondHalves = append(secondHalves, secondHalf)
+		if layout.RowWidth != 0 && len(innerList) != layout.RowWidth {
+			if team, column, ok := restingTeam(innerList, known); ok {
+				b := min(max((column-layout.FirstColumn)/layout.BlockWidth, 0), layout.BlocksPerRow-1)
+				blocks[b] = append(blocks[b], calendarRow{row: i + 1, column: column + 1, bye: true, cells: []string{team}})
+				continue
+			}
+			reportSkippedRow(i+1, 1, innerList, fmt.Sprintf("row has %d cells, expected %d", len(innerList), layout.RowWidth), report, mode)
+			continue
+		}
+
+		for b := 0; b < layout.BlocksPerRow; b++ {
+			start := layout.blockStart(b)
+			cells, empty := blockCells(innerList, start, layout)
+			if empty {
+				continue
+			}
+			end := min(start+layout.BlockWidth, len(innerList))
+			if team, column, ok := restingTeam(innerList[start:end], known); ok {
+				blocks[b] = append(blocks[b], calendarRow{row: i + 1, column: start + column + 1, bye: true, cells: []string{team}})
+				continue
+			}
+			if layout.RowWidth == 0 && (strings.TrimSpace(cells[fieldHomeTeam]) == "" || strings.TrimSpace(cells[fieldAwayTeam]) == "") {
+				reportSkippedRow(i+1, start+1, innerList[start:end], "block does not name two teams", report, mode)
+				continue
+			}
+			blocks[b] = append(blocks[b], calendarRow{row: i + 1, column: start + 1, cells: cells})
+		}
 	}
 
-	result = append(result, firstHalves...)
-	result = append(result, secondHalves...)
+	for _, block := range blocks {
+		result = append(result, block...)
+	}
 
 	return result
 }
+
+// calendarTeams returns the teams playing the fixtures of a calendar, so
+// that a row naming one of them alone can be told from a title.
+func calendarTeams(rows [][]string, layout Layout) map[string]bool {
+	known := make(map[string]bool)
+	for _, row := range rows {
+		if hasMarker(row, layout.Marker) {
+			continue
+		}
+		for b := 0; b < layout.BlocksPerRow; b++ {
+			cells, _ := blockCells(row, layout.blockStart(b), layout)
+			home, away := teams.Clean(cells[fieldHomeTeam]), teams.Clean(cells[fieldAwayTeam])
+			if home == "" || away == "" || byePattern.MatchString(home) || byePattern.MatchString(away) {
+				continue
+			}
+			if resultPattern.MatchString(cells[fieldResult]) {
+				known[home], known[away] = true, true
+			}
+		}
+	}
+	return known
+}
+
+// restingTeam finds a bye in a row or block: a single team next to "Riposo",
+// or a team of the calendar named alone. Fantasy points and dashes next to
+// the team are ignored. It returns the team and the 0-based column it was
+// found at.
+func restingTeam(cells []string, known map[string]bool) (string, int, bool) {
+	team, column, keyword := "", -1, false
+	for i, cell := range cells {
+		cell = strings.TrimSpace(cell)
+		switch {
+		case cell == "" || cell == "-":
+		case byePattern.MatchString(cell):
+			keyword = true
+		default:
+			if _, err := parseFantasyPoints(cell); err == nil {
+				continue
+			}
+			if team != "" {
+				return "", 0, false
+			}
+			team, column = cell, i
+		}
+	}
+	if team == "" || !(keyword || known[teams.Clean(team)]) {
+		return "", 0, false
+	}
+	return team, column, true
+}
+
+// splitMarkers returns, indexed by block, the matchday markers found in a
+// row. Rows reaching the last block are matched to blocks by position,
+// shorter ones (the empty cells were dropped) in reading order.
+func splitMarkers(row int, cells []string, layout Layout) []calendarRow {
+	var markers []calendarRow
+	positional := len(cells) > layout.blockStart(layout.BlocksPerRow-1)
+	found := 0
+
+	for column, cell := range cells {
+		if !layout.Marker.MatchString(cell) {
+			continue
+		}
+		b := found
+		if positional {
+			b = (column - layout.FirstColumn) / layout.BlockWidth
+		}
+		found++
+		if b < 0 || b >= layout.BlocksPerRow {
+			continue
+		}
+		if markers == nil {
+			markers = make([]calendarRow, layout.BlocksPerRow)
+		}
+		if !markers[b].marker {
+			markers[b] = calendarRow{row: row, column: column + 1, marker: true, cells: []string{cell}}
+		}
+	}
+
+	return markers
+}
+
+// blockCells picks the fields of the block starting at start, leaving the
+// ones past the end of the row empty.
+func blockCells(row []string, start int, layout Layout) ([]string, bool) {
+	cells := make([]string, fieldCount)
+	empty := true
+	for field, offset := range layout.offsets() {
+		if start+offset < len(row) {
+			cells[field] = row[start+offset]
+		}
+		if strings.TrimSpace(cells[field]) != "" {
+			empty = false
+		}
+	}
+	return cells, empty
+}
+
+// reportSkippedRow explains why cells were left out. Cells holding a result
+// are fixtures with missing or extra cells, anything else is just not
+// calendar data.
+func reportSkippedRow(row, firstColumn int, cells []string, reason string, report *ParseReport, mode Mode) {
+	if strings.TrimSpace(strings.Join(cells, "")) == "" {
+		return
+	}
+
+	for column, cell := range cells {
+		if resultPattern.MatchString(cell) {
+			report.problem(mode, Diagnostic{
+				Row:     row,
+				Column:  firstColumn + column,
+				Code:    CodeMalformedRow,
+				Value:   strings.Join(cells, " | "),
+				Message: "fixture " + reason,
+			})
+			return
+		}
+	}
+
+	report.warn(Diagnostic{
+		Row:     row,
+		Column:  firstColumn,
+		Code:    CodeSkippedRow,
+		Value:   strings.Join(cells, " | "),
+		Message: reason,
+	})
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -46,48 +47,75 @@ func TestCalculateMatchPoints(t *testing.T) {
 
 func TestGetTeamResult(t *testing.T) {
 	tests := []struct {
-		name  string
-		match []string
-		want  []TeamResult
+		name    string
+		match   []string
+		want    []TeamResult
+		wantErr bool
 	}{
 		{
 			name:  "Valid Match Row",
-			match: []string{"TeamA", "P1", "G1", "TeamB", "2-1", "P2", "G2", "TeamC", "P3", "G3"}, // Only first 5 elements matter for getTeamResult
+			match: []string{"TeamA", "72.5", "66", "TeamB", "2-1", "P2", "G2", "TeamC", "P3", "G3"}, // Only first 5 elements matter for getTeamResult
 			want: []TeamResult{
-				{Team: "TeamA", Goals: 2, Points: 3},
-				{Team: "TeamB", Goals: 1, Points: 0},
+				{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+				{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 66},
+			},
+		},
+		{
+			name:  "Team names are cleaned",
+			match: []string{" FC  Pippo ", "72.5", "66", "Team\u00a0B", "2-1"},
+			want: []TeamResult{
+				{Team: "FC Pippo", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+				{Team: "Team B", Goals: 1, Points: 0, FantasyPoints: 66},
 			},
 		},
 		{
 			name:  "Draw Match Row",
-			match: []string{"TeamX", "P1", "G1", "TeamY", "0-0", "P2", "G2", "TeamZ", "P3", "G3"},
+			match: []string{"TeamX", "61", "64.5", "TeamY", "0-0", "P2", "G2", "TeamZ", "P3", "G3"},
 			want: []TeamResult{
-				{Team: "TeamX", Goals: 0, Points: 1},
-				{Team: "TeamY", Goals: 0, Points: 1},
+				{Team: "TeamX", Home: true, Goals: 0, Points: 1, FantasyPoints: 61},
+				{Team: "TeamY", Goals: 0, Points: 1, FantasyPoints: 64.5},
 			},
 		},
 		{
 			name:  "Loss Match Row",
-			match: []string{"TeamM", "P1", "G1", "TeamN", "1-3", "P2", "G2", "TeamO", "P3", "G3"},
+			match: []string{"TeamM", "66", "78", "TeamN", "1-3", "P2", "G2", "TeamO", "P3", "G3"},
+			want: []TeamResult{
+				{Team: "TeamM", Home: true, Goals: 1, Points: 0, FantasyPoints: 66},
+				{Team: "TeamN", Goals: 3, Points: 3, FantasyPoints: 78},
+			},
+		},
+		{
+			name:  "Italian Decimal Separator",
+			match: []string{"TeamA", "72,5", "66,5", "TeamB", "2-1"},
 			want: []TeamResult{
-				{Team: "TeamM", Goals: 1, Points: 0},
-				{Team: "TeamN", Goals: 3, Points: 3},
+				{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+				{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 66.5},
 			},
 		},
+		{
+			name:    "Unparsable Home Fantasy Points",
+			match:   []string{"TeamA", "P1", "66", "TeamB", "2-1"},
+			wantErr: true,
+		},
+		{
+			name:    "Unparsable Away Fantasy Points",
+			match:   []string{"TeamA", "72.5", "", "TeamB", "2-1"},
+			wantErr: true,
+		},
 		{
 			name:  "Insufficient Columns",
 			match: []string{"TeamA", "P1", "G1", "TeamB"},
 			want:  []TeamResult{},
 		},
 		{
-			name:  "Invalid Goals Format (No Dash)",
-			match: []string{"TeamA", "P1", "G1", "TeamB", "21"},
-			want:  []TeamResult{},
+			name:    "Invalid Goals Format (No Dash)",
+			match:   []string{"TeamA", "P1", "G1", "TeamB", "21"},
+			wantErr: true,
 		},
 		{
-			name:  "Invalid Goals Format (Non-Numeric)",
-			match: []string{"TeamA", "P1", "G1", "TeamB", "a-b"},
-			want:  []TeamResult{},
+			name:    "Invalid Goals Format (Non-Numeric)",
+			match:   []string{"TeamA", "P1", "G1", "TeamB", "a-b"},
+			wantErr: true,
 		},
 		{
 			name:  "Empty Goal String",
@@ -95,14 +123,19 @@ func TestGetTeamResult(t *testing.T) {
 			want:  []TeamResult{},
 		},
 		{
-			name:  "Empty First Goal String",
-			match: []string{"TeamA", "P1", "G1", "TeamB", "-1"},
+			name:  "Not Played Yet",
+			match: []string{"TeamA", "", "", "TeamB", ""},
 			want:  []TeamResult{},
 		},
 		{
-			name:  "Empty Second Goal String",
-			match: []string{"TeamA", "P1", "G1", "TeamB", "1-"},
-			want:  []TeamResult{},
+			name:    "Empty First Goal String",
+			match:   []string{"TeamA", "P1", "G1", "TeamB", "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "Empty Second Goal String",
+			match:   []string{"TeamA", "P1", "G1", "TeamB", "1-"},
+			wantErr: true,
 		},
 		{
 			name:  "Empty Match Slice",
@@ -113,7 +146,11 @@ func TestGetTeamResult(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := getTeamResult(tt.match)
+			got, err := getTeamResult(tt.match)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getTeamResult(%v) error = %v, wantErr %v", tt.match, err, tt.wantErr)
+				return
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getTeamResult(%v) = %v; want %v", tt.match, got, tt.want)
 			}
@@ -121,11 +158,41 @@ func TestGetTeamResult(t *testing.T) {
 	}
 }
 
+func TestParseFantasyPoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "Dot Decimal", value: "72.5", want: 72.5},
+		{name: "Comma Decimal", value: "72,5", want: 72.5},
+		{name: "Integer", value: "66", want: 66},
+		{name: "Surrounding Spaces", value: " 68,5 ", want: 68.5},
+		{name: "Empty", value: "", wantErr: true},
+		{name: "Text", value: "n.d.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFantasyPoints(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFantasyPoints(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseFantasyPoints(%q) = %v; want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSplitRows(t *testing.T) {
 	tests := []struct {
-		name string
-		rows [][]string
-		want [][]string
+		name         string
+		rows         [][]string
+		want         []calendarRow
+		wantWarnings []string
 	}{
 		{
 			name: "Standard 10-Column Rows",
@@ -133,11 +200,11 @@ func TestSplitRows(t *testing.T) {
 				{"A1", "A2", "A3", "A4", "A5", "B1", "B2", "B3", "B4", "B5"},
 				{"C1", "C2", "C3", "C4", "C5", "D1", "D2", "D3", "D4", "D5"},
 			},
-			want: [][]string{
-				{"A1", "A2", "A3", "A4", "A5"},
-				{"C1", "C2", "C3", "C4", "C5"},
-				{"B1", "B2", "B3", "B4", "B5"},
-				{"D1", "D2", "D3", "D4", "D5"},
+			want: []calendarRow{
+				{row: 1, column: 1, cells: []string{"A1", "A2", "A3", "A4", "A5"}},
+				{row: 2, column: 1, cells: []string{"C1", "C2", "C3", "C4", "C5"}},
+				{row: 1, column: 6, cells: []string{"B1", "B2", "B3", "B4", "B5"}},
+				{row: 2, column: 6, cells: []string{"D1", "D2", "D3", "D4", "D5"}},
 			},
 		},
 		{
@@ -147,12 +214,13 @@ func TestSplitRows(t *testing.T) {
 				{"C1", "C2", "C3", "C4", "C5"}, // This row should be skipped
 				{"E1", "E2", "E3", "E4", "E5", "F1", "F2", "F3", "F4", "F5"},
 			},
-			want: [][]string{
-				{"A1", "A2", "A3", "A4", "A5"},
-				{"E1", "E2", "E3", "E4", "E5"},
-				{"B1", "B2", "B3", "B4", "B5"},
-				{"F1", "F2", "F3", "F4", "F5"},
+			want: []calendarRow{
+				{row: 1, column: 1, cells: []string{"A1", "A2", "A3", "A4", "A5"}},
+				{row: 3, column: 1, cells: []string{"E1", "E2", "E3", "E4", "E5"}},
+				{row: 1, column: 6, cells: []string{"B1", "B2", "B3", "B4", "B5"}},
+				{row: 3, column: 6, cells: []string{"F1", "F2", "F3", "F4", "F5"}},
 			},
+			wantWarnings: []string{CodeSkippedRow},
 		},
 		{
 			name: "Empty Input",
@@ -165,16 +233,55 @@ func TestSplitRows(t *testing.T) {
 				{"A1", "A2"},
 				{"B1", "B2", "B3"},
 			},
+			want:         nil,
+			wantWarnings: []string{CodeSkippedRow, CodeSkippedRow},
+		},
+		{
+			name: "Marker Rows Lead Their Blocks",
+			rows: [][]string{
+				{"Giornata 1", "", "", "", "", "Giornata 2", "", "", "", ""},
+				{"A1", "A2", "A3", "A4", "A5", "B1", "B2", "B3", "B4", "B5"},
+			},
+			want: []calendarRow{
+				{row: 1, column: 1, marker: true, ordinal: 1, cells: []string{"Giornata 1"}},
+				{row: 2, column: 1, cells: []string{"A1", "A2", "A3", "A4", "A5"}},
+				{row: 1, column: 6, marker: true, ordinal: 2, cells: []string{"Giornata 2"}},
+				{row: 2, column: 6, cells: []string{"B1", "B2", "B3", "B4", "B5"}},
+			},
+		},
+		{
+			name: "Marker Row Without Empty Cells",
+			rows: [][]string{
+				{"1a Giornata lega", "2a Giornata lega"},
+				{"A1", "A2", "A3", "A4", "A5", "B1", "B2", "B3", "B4", "B5"},
+			},
+			want: []calendarRow{
+				{row: 1, column: 1, marker: true, ordinal: 1, cells: []string{"1a Giornata lega"}},
+				{row: 2, column: 1, cells: []string{"A1", "A2", "A3", "A4", "A5"}},
+				{row: 1, column: 2, marker: true, ordinal: 2, cells: []string{"2a Giornata lega"}},
+				{row: 2, column: 6, cells: []string{"B1", "B2", "B3", "B4", "B5"}},
+			},
+		},
+		{
+			name: "Blank Rows Are Not Reported",
+			rows: [][]string{
+				{},
+				{"", ""},
+			},
 			want: nil,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := splitRows(tt.rows)
+			report := &ParseReport{}
+			got := splitRows(tt.rows, DefaultLayout, report, ModeLenient)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("splitRows(%v) = %v; want %v", tt.rows, got, tt.want)
 			}
+			if codes := diagnosticCodes(report.Warnings); !reflect.DeepEqual(codes, tt.wantWarnings) {
+				t.Errorf("splitRows(%v) warnings = %v; want %v", tt.rows, codes, tt.wantWarnings)
+			}
 		})
 	}
 }
@@ -192,15 +299,29 @@ func TestGetTeamResults(t *testing.T) {
 			name: "Single Match Day with Giornata Marker",
 			calendar: [][]string{
 				{"Giornata 1", "", "", "", "", "", "", "", "", ""},
-				{"TeamA", "P1", "G1", "TeamB", "2-1", "TeamC", "P2", "G2", "TeamD", "0-0"},
+				{"TeamA", "72.5", "66", "TeamB", "2-1", "TeamC", "60", "63,5", "TeamD", "0-0"},
 			},
 			want: []MatchResults{
 				{
+					Matchday: 1,
+					Label:    "Giornata 1",
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+							Away:   TeamResult{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 66},
+							Result: "2-1", Row: 2, Column: 1, Cell: "A2",
+						},
+						{
+							Home:   TeamResult{Team: "TeamC", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+							Away:   TeamResult{Team: "TeamD", Goals: 0, Points: 1, FantasyPoints: 63.5},
+							Result: "0-0", Row: 2, Column: 6, Cell: "F2",
+						},
+					},
 					TeamResults: []TeamResult{
-						{Team: "TeamA", Goals: 2, Points: 3},
-						{Team: "TeamB", Goals: 1, Points: 0},
-						{Team: "TeamC", Goals: 0, Points: 1},
-						{Team: "TeamD", Goals: 0, Points: 1},
+						{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 72.5},
+						{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 66},
+						{Team: "TeamC", Home: true, Goals: 0, Points: 1, FantasyPoints: 60},
+						{Team: "TeamD", Goals: 0, Points: 1, FantasyPoints: 63.5},
 					},
 				},
 			},
@@ -215,19 +336,47 @@ func TestGetTeamResults(t *testing.T) {
 			},
 			want: []MatchResults{
 				{
+					Matchday: 1,
+					Label:    "Giornata 1",
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 1},
+							Away:   TeamResult{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 1},
+							Result: "1-0", Row: 2, Column: 1, Cell: "A2",
+						},
+						{
+							Home:   TeamResult{Team: "TeamC", Home: true, Goals: 2, Points: 1, FantasyPoints: 2},
+							Away:   TeamResult{Team: "TeamD", Goals: 2, Points: 1, FantasyPoints: 2},
+							Result: "2-2", Row: 3, Column: 1, Cell: "A3",
+						},
+					},
 					TeamResults: []TeamResult{
-						{Team: "TeamA", Goals: 1, Points: 3},
-						{Team: "TeamB", Goals: 0, Points: 0},
-						{Team: "TeamC", Goals: 2, Points: 1},
-						{Team: "TeamD", Goals: 2, Points: 1},
+						{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 1},
+						{Team: "TeamB", Goals: 0, Points: 0, FantasyPoints: 1},
+						{Team: "TeamC", Home: true, Goals: 2, Points: 1, FantasyPoints: 2},
+						{Team: "TeamD", Goals: 2, Points: 1, FantasyPoints: 2},
 					},
 				},
 				{
+					Matchday: 2,
+					Label:    "Giornata 2",
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 1},
+							Away:   TeamResult{Team: "TeamC", Goals: 0, Points: 0, FantasyPoints: 1},
+							Result: "1-0", Row: 2, Column: 6, Cell: "F2",
+						},
+						{
+							Home:   TeamResult{Team: "TeamB", Home: true, Goals: 3, Points: 3, FantasyPoints: 4},
+							Away:   TeamResult{Team: "TeamD", Goals: 0, Points: 0, FantasyPoints: 4},
+							Result: "3-0", Row: 3, Column: 6, Cell: "F3",
+						},
+					},
 					TeamResults: []TeamResult{
-						{Team: "TeamA", Goals: 1, Points: 3},
-						{Team: "TeamC", Goals: 0, Points: 0},
-						{Team: "TeamB", Goals: 3, Points: 3},
-						{Team: "TeamD", Goals: 0, Points: 0},
+						{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 1},
+						{Team: "TeamC", Goals: 0, Points: 0, FantasyPoints: 1},
+						{Team: "TeamB", Home: true, Goals: 3, Points: 3, FantasyPoints: 4},
+						{Team: "TeamD", Goals: 0, Points: 0, FantasyPoints: 4},
 					},
 				},
 			},
@@ -249,24 +398,132 @@ func TestGetTeamResults(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "Calendar with invalid rows (Giornata 2 not played yet)",
+			name: "Calendar with Giornata 2 not played yet",
 			calendar: [][]string{
 				{"Giornata 1", "", "", "", "", "Giornata 2", "", "", "", ""},
-				{"TeamA", "P1", "G1", "TeamB", "2-1", "TeamA", "P1", "G1", "TeamC", ""},
-				{"TeamC", "P1", "G1", "TeamD", "2-1", "TeamB", "P1", "G1", "TeamD", ""},
+				{"TeamA", "70", "65", "TeamB", "2-1", "TeamA", "P1", "G1", "TeamC", ""},
+				{"TeamC", "71,5", "67", "TeamD", "2-1", "TeamB", "P1", "G1", "TeamD", ""},
 			},
 			want: []MatchResults{
 				{
+					Matchday: 1,
+					Label:    "Giornata 1",
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 70},
+							Away:   TeamResult{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 65},
+							Result: "2-1", Row: 2, Column: 1, Cell: "A2",
+						},
+						{
+							Home:   TeamResult{Team: "TeamC", Home: true, Goals: 2, Points: 3, FantasyPoints: 71.5},
+							Away:   TeamResult{Team: "TeamD", Goals: 1, Points: 0, FantasyPoints: 67},
+							Result: "2-1", Row: 3, Column: 1, Cell: "A3",
+						},
+					},
 					TeamResults: []TeamResult{
-						{Team: "TeamA", Goals: 2, Points: 3},
-						{Team: "TeamB", Goals: 1, Points: 0},
-						{Team: "TeamC", Goals: 2, Points: 3},
-						{Team: "TeamD", Goals: 1, Points: 0},
+						{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 70},
+						{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 65},
+						{Team: "TeamC", Home: true, Goals: 2, Points: 3, FantasyPoints: 71.5},
+						{Team: "TeamD", Goals: 1, Points: 0, FantasyPoints: 67},
+					},
+				},
+				{
+					Matchday:    2,
+					Label:       "Giornata 2",
+					TeamResults: []TeamResult{},
+					Postponed: []Fixture{
+						{Home: TeamResult{Team: "TeamA", Home: true}, Away: TeamResult{Team: "TeamC"}, Row: 2, Column: 6, Cell: "F2"},
+						{Home: TeamResult{Team: "TeamB", Home: true}, Away: TeamResult{Team: "TeamD"}, Row: 3, Column: 6, Cell: "F3"},
 					},
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "Rounds are returned in matchday order",
+			calendar: [][]string{
+				{"1a Giornata lega (3a giornata serie A)", "", "", "", "", "2a Giornata lega (4a giornata serie A)", "", "", "", ""},
+				{"TeamA", "70", "65", "TeamB", "2-1", "TeamA", "60", "66", "TeamB", "0-1"},
+				{"3a Giornata lega (5a giornata serie A)", "", "", "", "", "4a Giornata lega (7a giornata serie A)", "", "", "", ""},
+				{"TeamB", "66", "66", "TeamA", "1-1", "TeamB", "59", "72", "TeamA", "0-2"},
+			},
+			want: []MatchResults{
+				{
+					Matchday:       1,
+					Label:          "1a Giornata lega (3a giornata serie A)",
+					SerieAMatchday: 3,
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 70},
+							Away:   TeamResult{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 65},
+							Result: "2-1", Row: 2, Column: 1, Cell: "A2",
+						},
+					},
+					TeamResults: []TeamResult{
+						{Team: "TeamA", Home: true, Goals: 2, Points: 3, FantasyPoints: 70},
+						{Team: "TeamB", Goals: 1, Points: 0, FantasyPoints: 65},
+					},
+				},
+				{
+					Matchday:       2,
+					Label:          "2a Giornata lega (4a giornata serie A)",
+					SerieAMatchday: 4,
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamA", Home: true, Goals: 0, Points: 0, FantasyPoints: 60},
+							Away:   TeamResult{Team: "TeamB", Goals: 1, Points: 3, FantasyPoints: 66},
+							Result: "0-1", Row: 2, Column: 6, Cell: "F2",
+						},
+					},
+					TeamResults: []TeamResult{
+						{Team: "TeamA", Home: true, Goals: 0, Points: 0, FantasyPoints: 60},
+						{Team: "TeamB", Goals: 1, Points: 3, FantasyPoints: 66},
+					},
+				},
+				{
+					Matchday:       3,
+					Label:          "3a Giornata lega (5a giornata serie A)",
+					SerieAMatchday: 5,
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamB", Home: true, Goals: 1, Points: 1, FantasyPoints: 66},
+							Away:   TeamResult{Team: "TeamA", Goals: 1, Points: 1, FantasyPoints: 66},
+							Result: "1-1", Row: 4, Column: 1, Cell: "A4",
+						},
+					},
+					TeamResults: []TeamResult{
+						{Team: "TeamB", Home: true, Goals: 1, Points: 1, FantasyPoints: 66},
+						{Team: "TeamA", Goals: 1, Points: 1, FantasyPoints: 66},
+					},
+				},
+				{
+					Matchday:       4,
+					Label:          "4a Giornata lega (7a giornata serie A)",
+					SerieAMatchday: 7,
+					Fixtures: []Fixture{
+						{
+							Home:   TeamResult{Team: "TeamB", Home: true, Goals: 0, Points: 0, FantasyPoints: 59},
+							Away:   TeamResult{Team: "TeamA", Goals: 2, Points: 3, FantasyPoints: 72},
+							Result: "0-2", Row: 4, Column: 6, Cell: "F4",
+						},
+					},
+					TeamResults: []TeamResult{
+						{Team: "TeamB", Home: true, Goals: 0, Points: 0, FantasyPoints: 59},
+						{Team: "TeamA", Goals: 2, Points: 3, FantasyPoints: 72},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Calendar with unparsable fantasy points",
+			calendar: [][]string{
+				{"Giornata 1", "", "", "", "", "", "", "", "", ""},
+				{"TeamA", "70", "n.d.", "TeamB", "2-1", "TeamC", "60", "62", "TeamD", "0-0"},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -283,3 +540,414 @@ func TestGetTeamResults(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMatchResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		label    string
+		fallback int
+		want     MatchResults
+	}{
+		{
+			name:     "League and Serie A Rounds",
+			label:    "3a Giornata lega (5a giornata serie A)",
+			fallback: 1,
+			want:     MatchResults{Matchday: 3, Label: "3a Giornata lega (5a giornata serie A)", SerieAMatchday: 5, TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "Ordinal Indicator",
+			label:    "12ª Giornata",
+			fallback: 1,
+			want:     MatchResults{Matchday: 12, Label: "12ª Giornata", TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "Number After Giornata",
+			label:    "Giornata 7",
+			fallback: 1,
+			want:     MatchResults{Matchday: 7, Label: "Giornata 7", TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "Serie A Round Written After",
+			label:    "Giornata 2 (Serie A 4)",
+			fallback: 1,
+			want:     MatchResults{Matchday: 2, Label: "Giornata 2 (Serie A 4)", SerieAMatchday: 4, TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "League Number Before Serie A",
+			label:    "Giornata lega 12 (Serie A 14)",
+			fallback: 1,
+			want:     MatchResults{Matchday: 12, Label: "Giornata lega 12 (Serie A 14)", SerieAMatchday: 14, TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "Round Marker",
+			label:    "Round 3",
+			fallback: 1,
+			want:     MatchResults{Matchday: 3, Label: "Round 3", TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "Matchday Marker",
+			label:    "Matchday 5 (Serie A 6)",
+			fallback: 1,
+			want:     MatchResults{Matchday: 5, Label: "Matchday 5 (Serie A 6)", SerieAMatchday: 6, TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "Ordinal Turno Marker",
+			label:    "8° Turno",
+			fallback: 1,
+			want:     MatchResults{Matchday: 8, Label: "8° Turno", TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "English Ordinal Round",
+			label:    "2nd Round",
+			fallback: 1,
+			want:     MatchResults{Matchday: 2, Label: "2nd Round", TeamResults: []TeamResult{}},
+		},
+		{
+			name:     "No Number Falls Back to Marker Order",
+			label:    "Giornata",
+			fallback: 4,
+			want:     MatchResults{Matchday: 4, Label: "Giornata", TeamResults: []TeamResult{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newMatchResults(tt.label, tt.fallback)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newMatchResults(%q, %d) = %v; want %v", tt.label, tt.fallback, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	parserImpl := NewParserImpl()
+
+	calendar := [][]string{
+		{"Giornata 1", "", "", "", "", "Giornata 2", "", "", "", ""},
+		{"TeamA", "70", "n.d.", "TeamB", "2-1", "TeamA", "66", "60", "TeamC", "1-0"},
+		{"TeamC", "61", "62", "TeamD", "0-0", "TeamB", "64", "68", "TeamD", "1-x"},
+		{"TeamE", "66", "TeamF", "1-0"},
+		{"Calendario Lega"},
+	}
+
+	tests := []struct {
+		name         string
+		mode         Mode
+		wantErr      bool
+		wantResults  int
+		wantWarnings []Diagnostic
+		wantErrors   []Diagnostic
+	}{
+		{
+			name:        "Lenient Mode Skips and Warns",
+			mode:        ModeLenient,
+			wantResults: 2,
+			wantWarnings: []Diagnostic{
+				{Row: 4, Column: 4, Code: CodeMalformedRow, Severity: SeverityWarning, Cell: "D4"},
+				{Row: 5, Column: 1, Code: CodeSkippedRow, Severity: SeverityWarning, Cell: "A5"},
+				{Row: 2, Column: 3, Code: CodeInvalidFantasyPoints, Severity: SeverityWarning, Cell: "C2"},
+				{Row: 3, Column: 10, Code: CodeInvalidResult, Severity: SeverityWarning, Cell: "J3"},
+			},
+		},
+		{
+			name:    "Strict Mode Fails",
+			mode:    ModeStrict,
+			wantErr: true,
+			wantWarnings: []Diagnostic{
+				{Row: 5, Column: 1, Code: CodeSkippedRow, Severity: SeverityWarning, Cell: "A5"},
+			},
+			wantErrors: []Diagnostic{
+				{Row: 4, Column: 4, Code: CodeMalformedRow, Severity: SeverityError, Cell: "D4"},
+				{Row: 2, Column: 3, Code: CodeInvalidFantasyPoints, Severity: SeverityError, Cell: "C2"},
+				{Row: 3, Column: 10, Code: CodeInvalidResult, Severity: SeverityError, Cell: "J3"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report, err := parserImpl.Parse(calendar, tt.mode)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Errorf("Parse() error = %T, want *ParseError", err)
+				}
+			}
+			if len(got) != tt.wantResults {
+				t.Errorf("Parse() returned %d rounds, want %d", len(got), tt.wantResults)
+			}
+			if positions := diagnosticPositions(report.Warnings); !reflect.DeepEqual(positions, tt.wantWarnings) {
+				t.Errorf("Parse() warnings = %v, want %v", positions, tt.wantWarnings)
+			}
+			if positions := diagnosticPositions(report.Errors); !reflect.DeepEqual(positions, tt.wantErrors) {
+				t.Errorf("Parse() errors = %v, want %v", positions, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseByes(t *testing.T) {
+	tests := []struct {
+		name         string
+		parser       *ParserImpl
+		calendar     [][]string
+		wantByes     [][]string
+		wantWarnings []string
+	}{
+		{
+			name:   "Export With Riposo And A Team Alone",
+			parser: NewParserImpl(),
+			calendar: [][]string{
+				{"Giornata 1", "", "", "", "", "Giornata 2", "", "", "", ""},
+				{"TeamA", "70", "66", "TeamB", "2-1", "TeamC", "61", "60", "TeamA", "1-0"},
+				{"TeamC", "", "", "Riposo", "", "TeamB", "", "", "", ""},
+				{"Calendario Lega"},
+			},
+			wantByes:     [][]string{{"TeamC"}, {"TeamB"}},
+			wantWarnings: []string{"A4"},
+		},
+		{
+			name:   "Short Row With Riposo",
+			parser: NewDetectingParserImpl(),
+			calendar: [][]string{
+				{"Giornata 1"},
+				{"TeamA", "70", "66", "TeamB", "2-1"},
+				{"Riposa", "TeamC ", "68"},
+				{"Giornata 2"},
+				{"TeamC", "70", "66", "TeamA", "2-1"},
+				{"TeamB"},
+			},
+			wantByes: [][]string{{"TeamC"}, {"TeamB"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report, err := tt.parser.Parse(tt.calendar, ModeStrict)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var byes [][]string
+			for _, matchResults := range got {
+				byes = append(byes, matchResults.Byes)
+				if len(matchResults.TeamResults) != 2 {
+					t.Errorf("matchday %d has %d team results, want the 2 playing teams", matchResults.Matchday, len(matchResults.TeamResults))
+				}
+			}
+			if !reflect.DeepEqual(byes, tt.wantByes) {
+				t.Errorf("Parse() byes = %v, want %v", byes, tt.wantByes)
+			}
+
+			var warnings []string
+			for _, warning := range report.Warnings {
+				warnings = append(warnings, warning.Cell)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("Parse() warnings at %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestParsePostponed(t *testing.T) {
+	calendar := [][]string{
+		{"Giornata 1", "", "", "", "", "Giornata 2", "", "", "", ""},
+		{"TeamA", "70", "66", "TeamB", "2-1", "TeamA", "", "", "TeamC", ""},
+		{"TeamC", "61", "60", "TeamD", "1-0", "TeamB", "", "", "TeamD", "-"},
+	}
+
+	got, _, err := NewParserImpl().Parse(calendar, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Parse() = %d rounds, want the played one and the postponed one", len(got))
+	}
+	if got[0].Incomplete() {
+		t.Errorf("Parse() round 1 is incomplete, want it complete")
+	}
+	if len(got[1].TeamResults) != 0 || len(got[1].Postponed) != 2 || got[1].Incomplete() {
+		t.Errorf("Parse() round 2 = %+v, want both fixtures still to be played and the round not incomplete", got[1])
+	}
+
+	calendar[2] = []string{"TeamC", "", "", "TeamD", "", "TeamB", "", "", "TeamD", "-"}
+	got, _, err = NewParserImpl().Parse(calendar, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Fixture{
+		{Home: TeamResult{Team: "TeamC", Home: true}, Away: TeamResult{Team: "TeamD"}, Row: 3, Column: 1, Cell: "A3"},
+	}
+	if !reflect.DeepEqual(got[0].Postponed, want) {
+		t.Errorf("Parse() postponed = %+v, want %+v", got[0].Postponed, want)
+	}
+	if !got[0].Incomplete() {
+		t.Errorf("Parse() round 1 is complete, want it incomplete")
+	}
+}
+
+func TestParseRoundWithoutResults(t *testing.T) {
+	// Every fixture of round 2 was postponed and round 3 only has a bye: both
+	// are kept, so that the teams get their pending fixtures and byes counted.
+	calendar := [][]string{
+		{"Giornata 1", "", "", "", ""},
+		{"TeamA", "70", "66", "TeamB", "2-1"},
+		{"TeamC", "61", "60", "TeamD", "1-0"},
+		{"Giornata 2", "", "", "", ""},
+		{"TeamA", "", "", "TeamC", "-"},
+		{"TeamB", "", "", "TeamD", "-"},
+		{"Giornata 3", "", "", "", ""},
+		{"TeamA", "", "", "Riposo", ""},
+	}
+
+	got, _, err := NewDetectingParserImpl().Parse(calendar, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []MatchResults{
+		{
+			Matchday:    2,
+			Label:       "Giornata 2",
+			TeamResults: []TeamResult{},
+			Postponed: []Fixture{
+				{Home: TeamResult{Team: "TeamA", Home: true}, Away: TeamResult{Team: "TeamC"}, Row: 5, Column: 1, Cell: "A5"},
+				{Home: TeamResult{Team: "TeamB", Home: true}, Away: TeamResult{Team: "TeamD"}, Row: 6, Column: 1, Cell: "A6"},
+			},
+		},
+		{Matchday: 3, Label: "Giornata 3", TeamResults: []TeamResult{}, Byes: []string{"TeamA"}},
+	}
+	if len(got) != 3 {
+		t.Fatalf("Parse() = %d rounds, want 3", len(got))
+	}
+	if !reflect.DeepEqual(got[1:], want) {
+		t.Errorf("Parse() = %+v, want %+v", got[1:], want)
+	}
+}
+
+func TestParseUnnumberedMarkers(t *testing.T) {
+	// Side by side blocks whose titles carry no number: rounds are numbered
+	// in reading order, left block first on every row.
+	calendar := [][]string{
+		{"Giornata", "", "", "", "", "Giornata", "", "", "", ""},
+		{"TeamA", "70", "66", "TeamB", "2-1", "TeamA", "61", "60", "TeamC", "1-0"},
+		{"Giornata", "", "", "", "", "Giornata", "", "", "", ""},
+		{"TeamB", "70", "66", "TeamC", "2-1", "TeamC", "61", "60", "TeamA", "1-0"},
+	}
+
+	got, _, err := NewParserImpl().Parse(calendar, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[int]string{1: "TeamB", 2: "TeamC", 3: "TeamC", 4: "TeamA"}
+	if len(got) != len(want) {
+		t.Fatalf("Parse() = %d rounds, want %d", len(got), len(want))
+	}
+	for i, matchResults := range got {
+		if matchResults.Matchday != i+1 {
+			t.Errorf("Parse() round %d is matchday %d, want %d", i, matchResults.Matchday, i+1)
+		}
+		if away := matchResults.Fixtures[0].Away.Team; away != want[matchResults.Matchday] {
+			t.Errorf("Parse() matchday %d has %s away, want %s", matchResults.Matchday, away, want[matchResults.Matchday])
+		}
+	}
+}
+
+func TestParseSpacerColumn(t *testing.T) {
+	// Sheet grids keep the empty column that separates the two blocks.
+	calendar := [][]string{
+		{"Giornata 1", "", "", "", "", "", "Giornata 2", "", "", "", ""},
+		{"TeamA", "70", "66", "TeamB", "2-1", "", "TeamA", "61", "60", "TeamC", "1-0"},
+		{"TeamC", "61", "60", "TeamD", "1-0", "", "TeamB", "70", "66", "TeamD", "2-1"},
+	}
+
+	got, _, err := NewParserImpl().Parse(calendar, ModeStrict)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Parse() = %d rounds, want 2", len(got))
+	}
+	want := Fixture{
+		Home:   TeamResult{Team: "TeamA", Home: true, Goals: 1, Points: 3, FantasyPoints: 61},
+		Away:   TeamResult{Team: "TeamC", Goals: 0, Points: 0, FantasyPoints: 60},
+		Result: "1-0", Row: 2, Column: 7, Cell: "G2",
+	}
+	if !reflect.DeepEqual(got[1].Fixtures[0], want) {
+		t.Errorf("Parse() = %+v, want %+v", got[1].Fixtures[0], want)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Mode
+		wantErr bool
+	}{
+		{name: "", want: ModeLenient},
+		{name: "lenient", want: ModeLenient},
+		{name: "Strict", want: ModeStrict},
+		{name: "loose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMode(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMode(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMode(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// Helper functions
+
+func diagnosticCodes(diagnostics []Diagnostic) []string {
+	var codes []string
+	for _, d := range diagnostics {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+// diagnosticPositions keeps only the fields a test can state without
+// repeating the human readable message.
+func diagnosticPositions(diagnostics []Diagnostic) []Diagnostic {
+	var positions []Diagnostic
+	for _, d := range diagnostics {
+		positions = append(positions, Diagnostic{Row: d.Row, Column: d.Column, Code: d.Code, Severity: d.Severity, Cell: d.Cell})
+	}
+	return positions
+}
+
+func TestCellName(t *testing.T) {
+	tests := []struct {
+		column int
+		row    int
+		want   string
+	}{
+		{column: 1, row: 1, want: "A1"},
+		{column: 5, row: 14, want: "E14"},
+		{column: 26, row: 2, want: "Z2"},
+		{column: 27, row: 3, want: "AA3"},
+		{column: 703, row: 4, want: "AAA4"},
+		{column: 0, row: 4, want: ""},
+		{column: 2, row: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := cellName(tt.column, tt.row); got != tt.want {
+				t.Errorf("cellName(%d, %d) = %q; want %q", tt.column, tt.row, got, tt.want)
+			}
+		})
+	}
+}
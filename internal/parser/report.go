@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mode controls what happens when the calendar contains values the parser
+// cannot make sense of.
+type Mode int
+
+const (
+	// ModeLenient skips the offending fixtures and reports them as warnings.
+	ModeLenient Mode = iota
+	// ModeStrict reports them as errors and fails the parse.
+	ModeStrict
+)
+
+// ParseMode converts a user supplied mode name, defaulting to lenient.
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "lenient":
+		return ModeLenient, nil
+	case "strict":
+		return ModeStrict, nil
+	}
+	return ModeLenient, fmt.Errorf("parser: unknown mode %q", name)
+}
+
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Reason codes used in diagnostics.
+const (
+	CodeSkippedRow           = "skipped_row"
+	CodeMalformedRow         = "malformed_row"
+	CodeInvalidResult        = "invalid_result"
+	CodeInvalidFantasyPoints = "invalid_fantasy_points"
+	CodeLayoutNotDetected    = "layout_not_detected"
+	CodeSchemaViolation      = "schema_violation"
+	CodeDuplicateMatchday    = "duplicate_matchday"
+)
+
+// Diagnostic points at a single problem in the calendar. Row and Column are
+// 1-based and refer to the calendar grid handed to the parser, they are 0 for
+// problems with the calendar as a whole. When the grid keeps the sheet
+// positions, as excel grid mode does, Cell is the sheet cell, e.g. "E14".
+// Calendars sent as JSON have no rows: Path is then the JSON pointer of the
+// offending value, e.g. "/matchdays/0/fixtures/1/home/goals".
+type Diagnostic struct {
+	Row      int      `json:"row"`
+	Column   int      `json:"column"`
+	Code     string   `json:"code"`
+	Value    string   `json:"value"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Cell     string   `json:"cell,omitempty"`
+	Path     string   `json:"path,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	location := d.Cell
+	if location == "" {
+		location = d.Path
+	}
+	if location == "" {
+		return fmt.Sprintf("%s %q: %s", d.Code, d.Value, d.Message)
+	}
+	return fmt.Sprintf("%s: %s %q: %s", location, d.Code, d.Value, d.Message)
+}
+
+type ParseReport struct {
+	// Layout is the name of the layout the calendar was read with.
+	Layout string `json:"layout"`
+	// Confidence tells how well a detected layout fits the calendar, from 0
+	// to 1. It is 0 when the layout was not detected.
+	Confidence float64      `json:"confidence"`
+	Warnings   []Diagnostic `json:"warnings"`
+	Errors     []Diagnostic `json:"errors"`
+}
+
+func (r *ParseReport) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// warn records something the parser ignored on purpose, whatever the mode.
+func (r *ParseReport) warn(d Diagnostic) {
+	d.Severity = SeverityWarning
+	d.Cell = cellName(d.Column, d.Row)
+	r.Warnings = append(r.Warnings, d)
+}
+
+// problem records invalid data: an error in strict mode, a warning otherwise.
+func (r *ParseReport) problem(mode Mode, d Diagnostic) {
+	if mode == ModeStrict {
+		d.Severity = SeverityError
+		d.Cell = cellName(d.Column, d.Row)
+		r.Errors = append(r.Errors, d)
+		return
+	}
+	r.warn(d)
+}
+
+// cellName returns the spreadsheet name of a cell, e.g. "E14" for column 5,
+// row 14, or "" when the position is unknown.
+func cellName(column, row int) string {
+	if column < 1 || row < 1 {
+		return ""
+	}
+
+	name := ""
+	for column > 0 {
+		column--
+		name = string(rune('A'+column%26)) + name
+		column /= 26
+	}
+	return name + strconv.Itoa(row)
+}
+
+// ParseError is returned by a strict parse that found invalid data.
+type ParseError struct {
+	Report *ParseReport
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parser: calendar has %d error(s), first at %s", len(e.Report.Errors), e.Report.Errors[0])
+}
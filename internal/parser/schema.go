@@ -0,0 +1,224 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSchemaValueLength bounds the offending value quoted in a diagnostic.
+const maxSchemaValueLength = 60
+
+// jsonSchema validates decoded JSON documents against the subset of JSON
+// Schema that CalendarSchema uses: type, required, properties,
+// additionalProperties, items, minItems, minLength, minimum, maximum and
+// local $ref. Unknown keywords are ignored.
+type jsonSchema struct {
+	root map[string]any
+}
+
+func newJSONSchema(data []byte) (*jsonSchema, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parser: invalid JSON schema: %w", err)
+	}
+	return &jsonSchema{root: root}, nil
+}
+
+// validate returns a diagnostic for every violation, each at the JSON pointer
+// of the offending value. value must be decoded with json.Number numbers.
+func (s *jsonSchema) validate(value any) []Diagnostic {
+	var violations []Diagnostic
+	s.check(s.root, value, "", &violations)
+	return violations
+}
+
+func (s *jsonSchema) check(schema map[string]any, value any, path string, violations *[]Diagnostic) {
+	violate := func(format string, args ...any) {
+		*violations = append(*violations, Diagnostic{
+			Code:    CodeSchemaViolation,
+			Path:    path,
+			Value:   schemaValue(value),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			violate("%v", err)
+			return
+		}
+		s.check(target, value, path, violations)
+		return
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !hasSchemaType(types, value) {
+		violate("must be %s", strings.Join(types, " or "))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, name := range required {
+				if name, ok := name.(string); ok {
+					if _, found := v[name]; !found {
+						violate("missing required property %q", name)
+					}
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					*violations = append(*violations, Diagnostic{
+						Code:    CodeSchemaViolation,
+						Path:    path + "/" + escapePointer(name),
+						Value:   schemaValue(v[name]),
+						Message: fmt.Sprintf("unknown property %q", name),
+					})
+				}
+				continue
+			}
+			s.check(property, v[name], path+"/"+escapePointer(name), violations)
+		}
+	case []any:
+		if minItems, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < minItems {
+			violate("must have at least %v items", minItems)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				s.check(items, item, path+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	case string:
+		if minLength, ok := schemaNumber(schema["minLength"]); ok && float64(utf8.RuneCountInString(v)) < minLength {
+			violate("must be at least %v characters long", minLength)
+		}
+	case json.Number:
+		number, err := v.Float64()
+		if err != nil {
+			violate("is not a valid number")
+			return
+		}
+		if minimum, ok := schemaNumber(schema["minimum"]); ok && number < minimum {
+			violate("must be at least %v", minimum)
+		}
+		if maximum, ok := schemaNumber(schema["maximum"]); ok && number > maximum {
+			violate("must be at most %v", maximum)
+		}
+	}
+}
+
+// resolve follows a local reference such as "#/$defs/side".
+func (s *jsonSchema) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("schema reference %q is not local", ref)
+	}
+
+	var node any = s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("schema reference %q not found", ref)
+		}
+		node = object[token]
+	}
+
+	target, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("schema reference %q not found", ref)
+	}
+	return target, nil
+}
+
+func schemaTypes(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var types []string
+		for _, t := range v {
+			if t, ok := t.(string); ok {
+				types = append(types, t)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasSchemaType(types []string, value any) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case json.Number:
+			if t == "number" {
+				return true
+			}
+			// Any number without a fractional part is an integer, 2.0 and
+			// 2e1 included.
+			if f, err := v.Float64(); err == nil && t == "integer" && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func schemaNumber(value any) (float64, bool) {
+	number, ok := value.(float64)
+	return number, ok
+}
+
+// schemaValue quotes the offending value, shortened when it is a large
+// object or array.
+func schemaValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	if text := []rune(string(data)); len(text) > maxSchemaValueLength {
+		return string(text[:maxSchemaValueLength]) + "..."
+	}
+	return string(data)
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
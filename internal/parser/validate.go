@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reason codes of the calendar consistency checks.
+const (
+	CodeDuplicateTeam       = "duplicate_team"
+	CodeDuplicateRound      = "duplicate_round"
+	CodeOddTeamCount        = "odd_team_count"
+	CodeTeamCountMismatch   = "team_count_mismatch"
+	CodeMissingTeam         = "missing_team"
+	CodeResultContradiction = "result_contradicts_points"
+	CodeIncompleteRound     = "incomplete_round"
+)
+
+// Validate checks that the parsed calendar makes sense as a whole.
+//
+// Errors are data that would make the ranking wrong. A team plays twice in a
+// round, a matchday is listed twice, or a round repeats another one fixture
+// for fixture.
+//
+// Warnings are what a league may well intend but is often a mistake. The
+// league has an odd number of teams and no byes. A round has a different
+// number of teams, or misses some of them. A winner scored fewer fantasy
+// points than the loser. A round has fixtures still to be played.
+//
+// Resting teams and teams of postponed fixtures count as taking part in their
+// round. Teams are compared by name as written, so fold names first when they
+// may be spelled differently across rounds. Problems with a round point at its
+// first fixture, when the round has fixtures.
+func Validate(results []MatchResults) []Diagnostic {
+	var diagnostics []Diagnostic
+	report := func(severity Severity, matchResults MatchResults, code, value, message string) {
+		d := Diagnostic{Code: code, Value: value, Severity: severity, Message: message}
+		if len(matchResults.Fixtures) > 0 {
+			first := matchResults.Fixtures[0]
+			d.Row, d.Column, d.Cell = first.Row, first.Column, first.Cell
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	league := make(map[string]bool)
+	counts := make(map[int]int)
+	byes := 0
+	for _, matchResults := range results {
+		for _, team := range roundTeams(matchResults) {
+			league[team] = true
+		}
+		counts[len(roundTeams(matchResults))]++
+		byes += len(matchResults.Byes)
+	}
+	usual := 0
+	for count, rounds := range counts {
+		if rounds > counts[usual] || (rounds == counts[usual] && count > usual) {
+			usual = count
+		}
+	}
+
+	if len(league)%2 == 1 && byes == 0 {
+		diagnostics = append(diagnostics, Diagnostic{
+			Code:     CodeOddTeamCount,
+			Value:    strconv.Itoa(len(league)),
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("the league has %d teams, an odd number, but no round has a bye", len(league)),
+		})
+	}
+
+	matchdays := make(map[int]int)
+	rounds := make(map[string]int)
+	for i, matchResults := range results {
+		label := roundName(matchResults)
+
+		seen := make(map[string]bool)
+		for _, team := range roundEntries(matchResults) {
+			if seen[team] {
+				report(SeverityError, matchResults, CodeDuplicateTeam, team,
+					fmt.Sprintf("%s: %s plays more than once", label, team))
+			}
+			seen[team] = true
+		}
+
+		if matchResults.Matchday > 0 {
+			if first, ok := matchdays[matchResults.Matchday]; ok {
+				report(SeverityError, matchResults, CodeDuplicateMatchday, strconv.Itoa(matchResults.Matchday),
+					fmt.Sprintf("%s is listed more than once, first as %s", label, roundName(results[first])))
+			} else {
+				matchdays[matchResults.Matchday] = i
+			}
+		}
+
+		if fingerprint := roundFingerprint(matchResults); fingerprint != "" {
+			if first, ok := rounds[fingerprint]; ok {
+				report(SeverityError, matchResults, CodeDuplicateRound, matchResults.Label,
+					fmt.Sprintf("%s repeats every fixture of %s", label, roundName(results[first])))
+			} else {
+				rounds[fingerprint] = i
+			}
+		}
+
+		if count := len(roundTeams(matchResults)); count != usual {
+			report(SeverityWarning, matchResults, CodeTeamCountMismatch, strconv.Itoa(count),
+				fmt.Sprintf("%s has %d teams, most rounds have %d", label, count, usual))
+		}
+		if missing := missingTeams(league, seen); len(missing) > 0 {
+			report(SeverityWarning, matchResults, CodeMissingTeam, strings.Join(missing, ", "),
+				fmt.Sprintf("%s has no fixture for %s", label, strings.Join(missing, ", ")))
+		}
+
+		if matchResults.Incomplete() {
+			pending := make([]string, len(matchResults.Postponed))
+			for j, fixture := range matchResults.Postponed {
+				pending[j] = fixture.Home.Team + " - " + fixture.Away.Team
+			}
+			report(SeverityWarning, matchResults, CodeIncompleteRound, strconv.Itoa(len(pending)),
+				fmt.Sprintf("%s has fixtures still to be played: %s", label, strings.Join(pending, ", ")))
+		}
+
+		for _, fixture := range matchResults.Fixtures {
+			home, away := fixture.Home, fixture.Away
+			if (home.Goals > away.Goals && home.FantasyPoints < away.FantasyPoints) ||
+				(home.Goals < away.Goals && home.FantasyPoints > away.FantasyPoints) {
+				diagnostics = append(diagnostics, Diagnostic{
+					Row:      fixture.Row,
+					Column:   fixture.Column,
+					Code:     CodeResultContradiction,
+					Value:    fixture.Result,
+					Severity: SeverityWarning,
+					Message: fmt.Sprintf("%s: %s %s %s is won by the team with fewer fantasy points (%v - %v)",
+						label, home.Team, fixture.Result, away.Team, home.FantasyPoints, away.FantasyPoints),
+					Cell: fixture.Cell,
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// AddValidation records the findings of Validate. Like invalid data, errors
+// are only errors in strict mode and warnings otherwise.
+func (r *ParseReport) AddValidation(diagnostics []Diagnostic, mode Mode) {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError && mode == ModeStrict {
+			r.Errors = append(r.Errors, d)
+			continue
+		}
+		d.Severity = SeverityWarning
+		r.Warnings = append(r.Warnings, d)
+	}
+}
+
+// roundEntries returns the teams of a round as listed, then the ones of its
+// postponed fixtures and the resting ones.
+func roundEntries(matchResults MatchResults) []string {
+	names := make([]string, 0, len(matchResults.TeamResults)+2*len(matchResults.Postponed)+len(matchResults.Byes))
+	for _, teamResult := range matchResults.TeamResults {
+		names = append(names, teamResult.Team)
+	}
+	for _, fixture := range matchResults.Postponed {
+		names = append(names, fixture.Home.Team, fixture.Away.Team)
+	}
+	return append(names, matchResults.Byes...)
+}
+
+// roundTeams returns the distinct teams of a round, resting ones and the ones
+// still to play included.
+func roundTeams(matchResults MatchResults) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, team := range roundEntries(matchResults) {
+		if !seen[team] {
+			seen[team] = true
+			names = append(names, team)
+		}
+	}
+	return names
+}
+
+// roundName names a round in messages.
+func roundName(matchResults MatchResults) string {
+	if matchResults.Label != "" {
+		return fmt.Sprintf("%q", matchResults.Label)
+	}
+	return "matchday " + strconv.Itoa(matchResults.Matchday)
+}
+
+// roundFingerprint identifies the fixtures of a round whatever their order,
+// so that a copy-pasted round is told apart from a rematch with other scores.
+func roundFingerprint(matchResults MatchResults) string {
+	if len(matchResults.TeamResults) == 0 {
+		return ""
+	}
+	entries := make([]string, len(matchResults.TeamResults))
+	for i, teamResult := range matchResults.TeamResults {
+		entries[i] = fmt.Sprintf("%s\x00%d\x00%v", teamResult.Team, teamResult.Goals, teamResult.FantasyPoints)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\x01")
+}
+
+func missingTeams(league map[string]bool, present map[string]bool) []string {
+	var missing []string
+	for team := range league {
+		if !present[team] {
+			missing = append(missing, team)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
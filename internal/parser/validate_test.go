@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// round builds a parsed round from home, away pairs laid out one per sheet
+// row, starting at row 2.
+func round(matchday int, fixtures ...Fixture) MatchResults {
+	matchResults := MatchResults{Matchday: matchday, Label: "Giornata " + strconv.Itoa(matchday)}
+	for i, fixture := range fixtures {
+		fixture.Home.Home = true
+		fixture.Row, fixture.Column, fixture.Cell = i+2, 1, cellName(1, i+2)
+		matchResults.addFixture(fixture)
+	}
+	return matchResults
+}
+
+func resting(matchResults MatchResults, byes ...string) MatchResults {
+	matchResults.Byes = byes
+	return matchResults
+}
+
+func postponed(matchResults MatchResults, fixtures ...[2]string) MatchResults {
+	for _, fixture := range fixtures {
+		matchResults.Postponed = append(matchResults.Postponed, Fixture{Home: TeamResult{Team: fixture[0], Home: true}, Away: TeamResult{Team: fixture[1]}})
+	}
+	return matchResults
+}
+
+func played(home string, homePoints float64, homeGoals int, away string, awayPoints float64, awayGoals int) Fixture {
+	return Fixture{
+		Home:   TeamResult{Team: home, Goals: homeGoals, Points: calculateMatchPoints(homeGoals, awayGoals), FantasyPoints: homePoints},
+		Away:   TeamResult{Team: away, Goals: awayGoals, Points: calculateMatchPoints(awayGoals, homeGoals), FantasyPoints: awayPoints},
+		Result: strconv.Itoa(homeGoals) + "-" + strconv.Itoa(awayGoals),
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []MatchResults
+		expected []Diagnostic
+	}{
+		{
+			name: "Consistent calendar",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1), played("C", 60, 0, "D", 61, 0)),
+				round(2, played("A", 66, 1, "C", 66.5, 1), played("B", 59, 0, "D", 70, 1)),
+			},
+		},
+		{
+			name: "Team playing twice in a round",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1), played("A", 60, 0, "C", 61, 0)),
+			},
+			expected: []Diagnostic{
+				{Code: CodeOddTeamCount, Value: "3", Severity: SeverityWarning, Message: "the league has 3 teams, an odd number, but no round has a bye"},
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeDuplicateTeam, Value: "A", Severity: SeverityError, Message: `"Giornata 1": A plays more than once`},
+			},
+		},
+		{
+			name: "Odd league with byes",
+			results: []MatchResults{
+				resting(round(1, played("A", 72, 2, "B", 66, 1)), "C"),
+				resting(round(2, played("B", 59, 0, "C", 70, 1)), "A"),
+			},
+		},
+		{
+			name: "Copy-pasted round",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1)),
+				round(2, played("A", 72, 2, "B", 66, 1)),
+			},
+			expected: []Diagnostic{
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeDuplicateRound, Value: "Giornata 2", Severity: SeverityError, Message: `"Giornata 2" repeats every fixture of "Giornata 1"`},
+			},
+		},
+		{
+			name: "Duplicated matchday",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1)),
+				round(1, played("B", 60, 0, "A", 66, 1)),
+			},
+			expected: []Diagnostic{
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeDuplicateMatchday, Value: "1", Severity: SeverityError, Message: `"Giornata 1" is listed more than once, first as "Giornata 1"`},
+			},
+		},
+		{
+			name: "Team missing from a round",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1), played("C", 60, 0, "D", 61, 0)),
+				round(2, played("A", 66, 1, "C", 66.5, 1), played("B", 59, 0, "D", 70, 1)),
+				round(3, played("A", 66, 1, "B", 60, 0)),
+			},
+			expected: []Diagnostic{
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeTeamCountMismatch, Value: "2", Severity: SeverityWarning, Message: `"Giornata 3" has 2 teams, most rounds have 4`},
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeMissingTeam, Value: "C, D", Severity: SeverityWarning, Message: `"Giornata 3" has no fixture for C, D`},
+			},
+		},
+		{
+			name: "Round with a postponed fixture",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1), played("C", 60, 0, "D", 61, 0)),
+				postponed(round(2, played("A", 66, 1, "C", 66.5, 1)), [2]string{"B", "D"}),
+			},
+			expected: []Diagnostic{
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeIncompleteRound, Value: "1", Severity: SeverityWarning, Message: `"Giornata 2" has fixtures still to be played: B - D`},
+			},
+		},
+		{
+			name: "Round not played yet",
+			results: []MatchResults{
+				round(1, played("A", 72, 2, "B", 66, 1), played("C", 60, 0, "D", 61, 0)),
+				postponed(round(2), [2]string{"A", "C"}, [2]string{"B", "D"}),
+			},
+		},
+		{
+			name: "Result contradicting the fantasy totals",
+			results: []MatchResults{
+				round(1, played("A", 65, 1, "B", 66, 0)),
+			},
+			expected: []Diagnostic{
+				{Row: 2, Column: 1, Cell: "A2", Code: CodeResultContradiction, Value: "1-0", Severity: SeverityWarning, Message: `"Giornata 1": A 1-0 B is won by the team with fewer fantasy points (65 - 66)`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Validate(tt.results))
+		})
+	}
+}
+
+func TestParseReport_AddValidation(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{Code: CodeDuplicateRound, Severity: SeverityError},
+		{Code: CodeMissingTeam, Severity: SeverityWarning},
+	}
+
+	strict := &ParseReport{}
+	strict.AddValidation(diagnostics, ModeStrict)
+	assert.Equal(t, []Diagnostic{{Code: CodeDuplicateRound, Severity: SeverityError}}, strict.Errors)
+	assert.Equal(t, []Diagnostic{{Code: CodeMissingTeam, Severity: SeverityWarning}}, strict.Warnings)
+
+	lenient := &ParseReport{}
+	lenient.AddValidation(diagnostics, ModeLenient)
+	assert.Empty(t, lenient.Errors)
+	assert.Len(t, lenient.Warnings, 2)
+	assert.Equal(t, SeverityWarning, lenient.Warnings[0].Severity)
+}
@@ -0,0 +1,202 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fantalegheGO/internal/parser"
+)
+
+// CodeResultMismatch marks a written result that differs from the one the
+// rules give, see ModeCheck.
+const CodeResultMismatch = "result_mismatch"
+
+// Mode tells what the rules do with the results written in the calendar.
+type Mode int
+
+const (
+	// ModeOff trusts the written results.
+	ModeOff Mode = iota
+	// ModeReplace ranks the teams on the results the rules give.
+	ModeReplace
+	// ModeCheck ranks the teams on the written results and warns about every
+	// result the rules would have given differently.
+	ModeCheck
+)
+
+// ParseMode converts a user supplied mode name, defaulting to off.
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "off":
+		return ModeOff, nil
+	case "replace":
+		return ModeReplace, nil
+	case "check":
+		return ModeCheck, nil
+	}
+	return ModeOff, fmt.Errorf("rules: unknown mode %q", name)
+}
+
+// Rules turn the fantasy totals of a fixture into its result.
+type Rules struct {
+	// Thresholds are the ascending fantasy totals at which each goal is
+	// scored, e.g. 66 for the first goal or 60, 64, 68 for a table.
+	Thresholds []float64 `json:"thresholds"`
+	// Step adds a goal every Step points past the last threshold. 0 scores no
+	// goal past the table.
+	Step float64 `json:"step"`
+	// CloseGameMargin gives a 1-0 win to the team that leads by at least this
+	// many points when neither side reached a goal. 0 disables the rule.
+	CloseGameMargin float64 `json:"closeGameMargin"`
+	// DrawBand draws the fixtures won by less than this many points: the
+	// winner is brought down to the goals of the loser. It is applied after
+	// CloseGameMargin. 0 disables the rule.
+	DrawBand float64 `json:"drawBand"`
+	// HomeBonus is the bonus the league adds to the fantasy total of the home
+	// team. The totals in the calendar already include it, it is only taken
+	// off by Neutral.
+	HomeBonus float64 `json:"homeBonus"`
+}
+
+// Default returns the most common rules: a goal at 66 points, then one every
+// 6 points.
+func Default() Rules {
+	return Rules{Thresholds: []float64{66}, Step: 6}
+}
+
+// Parse reads rules sent as JSON, returning the default ones for an empty
+// text.
+func Parse(text string) (Rules, error) {
+	if strings.TrimSpace(text) == "" {
+		return Default(), nil
+	}
+
+	var r Rules
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&r); err != nil {
+		return Rules{}, fmt.Errorf("rules: invalid rules: %w", err)
+	}
+	if err := r.Validate(); err != nil {
+		return Rules{}, err
+	}
+	return r, nil
+}
+
+// Validate checks that the rules can score any fixture.
+func (r Rules) Validate() error {
+	if len(r.Thresholds) == 0 {
+		return fmt.Errorf("rules: at least one goal threshold is required")
+	}
+	if !sort.Float64sAreSorted(r.Thresholds) {
+		return fmt.Errorf("rules: goal thresholds must be ascending")
+	}
+	for i := 1; i < len(r.Thresholds); i++ {
+		if r.Thresholds[i] == r.Thresholds[i-1] {
+			return fmt.Errorf("rules: goal threshold %v is repeated", r.Thresholds[i])
+		}
+	}
+	if r.Step < 0 || r.CloseGameMargin < 0 || r.DrawBand < 0 || r.HomeBonus < 0 {
+		return fmt.Errorf("rules: step, close game margin, draw band and home bonus cannot be negative")
+	}
+	return nil
+}
+
+// Goals returns the goals scored with a fantasy total, before the modifiers
+// that depend on the opponent.
+func (r Rules) Goals(fantasyPoints float64) int {
+	goals := sort.Search(len(r.Thresholds), func(i int) bool {
+		return r.Thresholds[i] > fantasyPoints
+	})
+	if goals == len(r.Thresholds) && r.Step > 0 {
+		goals += int(math.Floor((fantasyPoints - r.Thresholds[goals-1]) / r.Step))
+	}
+	return goals
+}
+
+// Neutral returns the fantasy total of a team without the home bonus, as if
+// the match had been played on neutral ground.
+func (r Rules) Neutral(team parser.TeamResult) float64 {
+	if team.Home {
+		return team.FantasyPoints - r.HomeBonus
+	}
+	return team.FantasyPoints
+}
+
+// Result returns the goals of a fixture from the fantasy totals of its teams.
+func (r Rules) Result(homePoints, awayPoints float64) (homeGoals, awayGoals int) {
+	homeGoals, awayGoals = r.Goals(homePoints), r.Goals(awayPoints)
+
+	lead := math.Abs(homePoints - awayPoints)
+	if homeGoals == 0 && awayGoals == 0 && r.CloseGameMargin > 0 && lead >= r.CloseGameMargin {
+		if homePoints > awayPoints {
+			homeGoals = 1
+		} else {
+			awayGoals = 1
+		}
+	}
+	if homeGoals != awayGoals && r.DrawBand > 0 && lead < r.DrawBand {
+		homeGoals = min(homeGoals, awayGoals)
+		awayGoals = homeGoals
+	}
+	return homeGoals, awayGoals
+}
+
+// Apply recomputes or checks the fixtures of the calendar. In ModeReplace the
+// returned results carry the goals the rules give, in both their fixtures and
+// team results, and the calendar itself is left untouched. Points are left as
+// written: scoring them is up to the caller. In ModeCheck the results are
+// returned as they are, with a warning for every fixture the rules score
+// differently.
+func (r Rules) Apply(results []parser.MatchResults, mode Mode) ([]parser.MatchResults, []parser.Diagnostic) {
+	if mode == ModeOff {
+		return results, nil
+	}
+
+	var warnings []parser.Diagnostic
+	applied := make([]parser.MatchResults, len(results))
+	for i, matchResults := range results {
+		applied[i] = matchResults
+		if mode == ModeReplace {
+			applied[i].Fixtures = append([]parser.Fixture(nil), matchResults.Fixtures...)
+		}
+
+		for j := range applied[i].Fixtures {
+			fixture := &applied[i].Fixtures[j]
+			home, away := &fixture.Home, &fixture.Away
+			homeGoals, awayGoals := r.Result(home.FantasyPoints, away.FantasyPoints)
+			if homeGoals == home.Goals && awayGoals == away.Goals {
+				continue
+			}
+
+			result := strconv.Itoa(homeGoals) + "-" + strconv.Itoa(awayGoals)
+			if mode == ModeCheck {
+				warnings = append(warnings, parser.Diagnostic{
+					Row:      fixture.Row,
+					Column:   fixture.Column,
+					Code:     CodeResultMismatch,
+					Value:    fixture.Result,
+					Severity: parser.SeverityWarning,
+					Message: fmt.Sprintf("matchday %d: rules give %s %s %s (%v - %v)",
+						matchResults.Matchday, home.Team, result, away.Team, home.FantasyPoints, away.FantasyPoints),
+					Cell: fixture.Cell,
+				})
+				continue
+			}
+			home.Goals, away.Goals = homeGoals, awayGoals
+			fixture.Result = result
+		}
+
+		if mode == ModeReplace && len(matchResults.Fixtures) > 0 {
+			applied[i].TeamResults = make([]parser.TeamResult, 0, 2*len(applied[i].Fixtures))
+			for _, fixture := range applied[i].Fixtures {
+				applied[i].TeamResults = append(applied[i].TeamResults, fixture.Home, fixture.Away)
+			}
+		}
+	}
+	return applied, warnings
+}
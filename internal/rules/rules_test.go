@@ -0,0 +1,155 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fantalegheGO/internal/parser"
+)
+
+func TestRules_Goals(t *testing.T) {
+	tests := []struct {
+		name          string
+		rules         Rules
+		fantasyPoints float64
+		expected      int
+	}{
+		{name: "Below the first goal", rules: Default(), fantasyPoints: 65.5, expected: 0},
+		{name: "First goal", rules: Default(), fantasyPoints: 66, expected: 1},
+		{name: "Just short of the second goal", rules: Default(), fantasyPoints: 71.5, expected: 1},
+		{name: "Every 6 points", rules: Default(), fantasyPoints: 84, expected: 4},
+		{name: "Table", rules: Rules{Thresholds: []float64{60, 64, 68}}, fantasyPoints: 65, expected: 2},
+		{name: "Past a table without step", rules: Rules{Thresholds: []float64{60, 64, 68}}, fantasyPoints: 90, expected: 3},
+		{name: "Past a table with step", rules: Rules{Thresholds: []float64{60, 64, 68}, Step: 4}, fantasyPoints: 76, expected: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rules.Goals(tt.fantasyPoints))
+		})
+	}
+}
+
+func TestRules_Result(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        Rules
+		home, away   float64
+		expectedHome int
+		expectedAway int
+	}{
+		{name: "Thresholds only", rules: Default(), home: 73, away: 66.5, expectedHome: 2, expectedAway: 1},
+		{name: "Both under 66 and close", rules: Rules{Thresholds: []float64{66}, Step: 6, CloseGameMargin: 4}, home: 60, away: 63.5, expectedHome: 0, expectedAway: 0},
+		{name: "Both under 66 but 4 points apart", rules: Rules{Thresholds: []float64{66}, Step: 6, CloseGameMargin: 4}, home: 59.5, away: 63.5, expectedHome: 0, expectedAway: 1},
+		{name: "Close game margin ignored once a goal is scored", rules: Rules{Thresholds: []float64{66}, Step: 6, CloseGameMargin: 4}, home: 66, away: 72, expectedHome: 1, expectedAway: 2},
+		{name: "Win inside the draw band", rules: Rules{Thresholds: []float64{66}, Step: 6, DrawBand: 1}, home: 72, away: 71.5, expectedHome: 1, expectedAway: 1},
+		{name: "Win outside the draw band", rules: Rules{Thresholds: []float64{66}, Step: 6, DrawBand: 1}, home: 72, away: 71, expectedHome: 2, expectedAway: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, away := tt.rules.Result(tt.home, tt.away)
+			assert.Equal(t, tt.expectedHome, home)
+			assert.Equal(t, tt.expectedAway, away)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		expected    Rules
+		expectedErr string
+	}{
+		{name: "Empty text", text: "", expected: Default()},
+		{
+			name:     "Table with modifiers",
+			text:     `{"thresholds": [60, 64, 68], "step": 4, "closeGameMargin": 4, "drawBand": 0.5, "homeBonus": 2}`,
+			expected: Rules{Thresholds: []float64{60, 64, 68}, Step: 4, CloseGameMargin: 4, DrawBand: 0.5, HomeBonus: 2},
+		},
+		{name: "No thresholds", text: `{"step": 6}`, expectedErr: "rules: at least one goal threshold is required"},
+		{name: "Unsorted thresholds", text: `{"thresholds": [66, 60]}`, expectedErr: "rules: goal thresholds must be ascending"},
+		{name: "Repeated threshold", text: `{"thresholds": [60, 60]}`, expectedErr: "rules: goal threshold 60 is repeated"},
+		{name: "Negative step", text: `{"thresholds": [66], "step": -6}`, expectedErr: "rules: step, close game margin, draw band and home bonus cannot be negative"},
+		{name: "Negative home bonus", text: `{"thresholds": [66], "homeBonus": -1}`, expectedErr: "rules: step, close game margin, draw band and home bonus cannot be negative"},
+		{name: "Unknown field", text: `{"thresholds": [66], "bonus": 1}`, expectedErr: `rules: invalid rules: json: unknown field "bonus"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.text)
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, r)
+		})
+	}
+}
+
+func TestRules_Neutral(t *testing.T) {
+	r := Rules{Thresholds: []float64{66}, Step: 6, HomeBonus: 2}
+
+	assert.Equal(t, 65.5, r.Neutral(parser.TeamResult{Team: "A", Home: true, FantasyPoints: 67.5}))
+	assert.Equal(t, 67.5, r.Neutral(parser.TeamResult{Team: "B", FantasyPoints: 67.5}))
+}
+
+func TestRules_Apply(t *testing.T) {
+	a := parser.TeamResult{Team: "A", Home: true, Goals: 2, Points: 3, FantasyPoints: 73}
+	b := parser.TeamResult{Team: "B", Goals: 1, Points: 0, FantasyPoints: 66.5}
+	c := parser.TeamResult{Team: "C", Home: true, Goals: 1, Points: 3, FantasyPoints: 64}
+	d := parser.TeamResult{Team: "D", Goals: 0, Points: 0, FantasyPoints: 59}
+	results := []parser.MatchResults{
+		{
+			Matchday: 1,
+			Fixtures: []parser.Fixture{
+				{Home: a, Away: b, Result: "2-1", Row: 2, Column: 1, Cell: "A2"},
+				{Home: c, Away: d, Result: "1-0", Row: 2, Column: 6, Cell: "F2"},
+			},
+			TeamResults: []parser.TeamResult{a, b, c, d},
+		},
+	}
+
+	t.Run("Off", func(t *testing.T) {
+		applied, warnings := Default().Apply(results, ModeOff)
+		assert.Equal(t, results, applied)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		applied, warnings := Default().Apply(results, ModeReplace)
+		assert.Empty(t, warnings)
+
+		// Only the goals change: the points are scored by the caller.
+		c0 := parser.TeamResult{Team: "C", Home: true, Goals: 0, Points: 3, FantasyPoints: 64}
+		d0 := parser.TeamResult{Team: "D", Goals: 0, Points: 0, FantasyPoints: 59}
+		assert.Equal(t, []parser.Fixture{
+			{Home: a, Away: b, Result: "2-1", Row: 2, Column: 1, Cell: "A2"},
+			{Home: c0, Away: d0, Result: "0-0", Row: 2, Column: 6, Cell: "F2"},
+		}, applied[0].Fixtures)
+		assert.Equal(t, []parser.TeamResult{a, b, c0, d0}, applied[0].TeamResults)
+		assert.Equal(t, c, results[0].Fixtures[1].Home, "the calendar must be left untouched")
+		assert.Equal(t, c, results[0].TeamResults[2], "the calendar must be left untouched")
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		applied, warnings := Default().Apply(results, ModeCheck)
+		assert.Equal(t, results, applied)
+		assert.Equal(t, []parser.Diagnostic{
+			{
+				Row:      2,
+				Column:   6,
+				Code:     CodeResultMismatch,
+				Value:    "1-0",
+				Severity: parser.SeverityWarning,
+				Message:  "matchday 1: rules give C 0-0 D (64 - 59)",
+				Cell:     "F2",
+			},
+		}, warnings)
+	})
+}
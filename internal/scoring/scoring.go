@@ -0,0 +1,81 @@
+package scoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ScoringRules are the league points a team takes from a match. Both the
+// points of the standings and the expected value are scored with them.
+type ScoringRules struct {
+	Win  int `json:"win"`
+	Draw int `json:"draw"`
+	Loss int `json:"loss"`
+	// BonusMargin is the goal margin from which a win is worth Bonus points
+	// more, e.g. 3 for a bonus point when winning by 3 goals or more. 0
+	// disables the bonus.
+	BonusMargin int `json:"bonusMargin"`
+	Bonus       int `json:"bonus"`
+}
+
+// Default returns the usual 3 points for a win, 1 for a draw and none for a
+// loss.
+func Default() ScoringRules {
+	return ScoringRules{Win: 3, Draw: 1, Loss: 0}
+}
+
+// Parse reads scoring rules sent as JSON. Missing fields keep their default,
+// so {"win": 2} scores 2/1/0; an empty text gives the default rules.
+func Parse(text string) (ScoringRules, error) {
+	s := Default()
+	if strings.TrimSpace(text) == "" {
+		return s, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&s); err != nil {
+		return ScoringRules{}, fmt.Errorf("scoring: invalid scoring rules: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return ScoringRules{}, err
+	}
+	return s, nil
+}
+
+// Validate checks that a win is worth at least a draw and a draw at least a
+// loss.
+func (s ScoringRules) Validate() error {
+	if s.Win < s.Draw || s.Draw < s.Loss {
+		return fmt.Errorf("scoring: a win must be worth at least a draw and a draw at least a loss")
+	}
+	if s.BonusMargin < 0 || s.Bonus < 0 {
+		return fmt.Errorf("scoring: bonus margin and bonus cannot be negative")
+	}
+	if s.Bonus > 0 && s.BonusMargin == 0 {
+		return fmt.Errorf("scoring: a bonus needs the goal margin it is given from")
+	}
+	return nil
+}
+
+// IsZero tells whether no rules were given, as for the zero value of
+// options.
+func (s ScoringRules) IsZero() bool {
+	return s == ScoringRules{}
+}
+
+// Points returns the points a team takes from a match it ended with ourGoals
+// against theirGoals.
+func (s ScoringRules) Points(ourGoals, theirGoals int) int {
+	switch {
+	case ourGoals > theirGoals:
+		if s.BonusMargin > 0 && ourGoals-theirGoals >= s.BonusMargin {
+			return s.Win + s.Bonus
+		}
+		return s.Win
+	case ourGoals < theirGoals:
+		return s.Loss
+	}
+	return s.Draw
+}
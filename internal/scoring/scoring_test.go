@@ -0,0 +1,62 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoringRules_Points(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      ScoringRules
+		ourGoals   int
+		theirGoals int
+		expected   int
+	}{
+		{name: "Win", rules: Default(), ourGoals: 2, theirGoals: 1, expected: 3},
+		{name: "Draw", rules: Default(), ourGoals: 1, theirGoals: 1, expected: 1},
+		{name: "Loss", rules: Default(), ourGoals: 0, theirGoals: 1, expected: 0},
+		{name: "Two points for a win", rules: ScoringRules{Win: 2, Draw: 1}, ourGoals: 1, theirGoals: 0, expected: 2},
+		{name: "Win short of the bonus", rules: ScoringRules{Win: 3, Draw: 1, BonusMargin: 3, Bonus: 1}, ourGoals: 3, theirGoals: 1, expected: 3},
+		{name: "Win with the bonus", rules: ScoringRules{Win: 3, Draw: 1, BonusMargin: 3, Bonus: 1}, ourGoals: 4, theirGoals: 1, expected: 4},
+		{name: "No bonus for the loser", rules: ScoringRules{Win: 3, Draw: 1, BonusMargin: 3, Bonus: 1}, ourGoals: 1, theirGoals: 4, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rules.Points(tt.ourGoals, tt.theirGoals))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		expected    ScoringRules
+		expectedErr string
+	}{
+		{name: "Empty text", text: " ", expected: Default()},
+		{name: "Two points for a win", text: `{"win": 2}`, expected: ScoringRules{Win: 2, Draw: 1}},
+		{name: "Bonus point", text: `{"bonusMargin": 3, "bonus": 1}`, expected: ScoringRules{Win: 3, Draw: 1, BonusMargin: 3, Bonus: 1}},
+		{name: "Draw worth more than a win", text: `{"win": 1, "draw": 2}`, expectedErr: "scoring: a win must be worth at least a draw and a draw at least a loss"},
+		{name: "Negative bonus", text: `{"bonusMargin": 3, "bonus": -1}`, expectedErr: "scoring: bonus margin and bonus cannot be negative"},
+		{name: "Bonus without margin", text: `{"bonus": 1}`, expectedErr: "scoring: a bonus needs the goal margin it is given from"},
+		{name: "Unknown field", text: `{"lose": 0}`, expectedErr: `scoring: invalid scoring rules: json: unknown field "lose"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.text)
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, s)
+		})
+	}
+}
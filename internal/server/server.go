@@ -1,8 +1,13 @@
 package server
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	api "github.com/antpas14/fantalegheEV-api" // Alias as 'api' for cleaner usage
 	echo "github.com/labstack/echo/v4"
@@ -10,8 +15,29 @@ import (
 	"fantalegheGO/internal/calculate"
 	"fantalegheGO/internal/excel"
 	"fantalegheGO/internal/parser"
+	"fantalegheGO/internal/rules"
+	"fantalegheGO/internal/scoring"
+	"fantalegheGO/internal/teams"
 )
 
+// calculateResponse is returned instead of the bare ranks when the client asks
+// for the parse diagnostics. Unlike the bare ranks, its ranks carry the
+// matches played, byes and pending fixtures of every team.
+type calculateResponse struct {
+	Ranks  []calculate.Rank    `json:"ranks"`
+	Sheet  string              `json:"sheet"`
+	Report *parser.ParseReport `json:"report"`
+}
+
+// maxCalendarSize bounds the JSON calendars read by CalculateJSON. A whole
+// season takes a few tens of kilobytes.
+const maxCalendarSize = 1 << 20
+
+type parseErrorResponse struct {
+	Message string              `json:"message"`
+	Report  *parser.ParseReport `json:"report"`
+}
+
 type MyServer struct {
 	e                *echo.Echo
 	calculateService calculate.Calculate
@@ -20,9 +46,13 @@ type MyServer struct {
 func NewMyServer() *MyServer {
 	e := echo.New()
 
-	parserInstance := parser.NewParserImpl()
+	parserInstance := parser.NewDetectingParserImpl()
 	excelServiceInstance := excel.NewExcelService()
-	calculateServiceInstance := calculate.NewCalculateImpl(excelServiceInstance, parserInstance)
+	calculateServiceInstance := calculate.NewCalculateImpl(excelServiceInstance, parserInstance).
+		WithReader(excel.FormatCSV, excel.NewCSVService()).
+		WithReader(excel.FormatODS, excel.NewODSService()).
+		WithReader(excel.FormatXLS, excel.NewXLSService()).
+		WithHTMLParser(parser.NewHTMLParserImpl())
 
 	server := &MyServer{
 		e:                e,
@@ -35,6 +65,11 @@ func NewMyServer() *MyServer {
 
 func (s *MyServer) setupRoutes() {
 	api.RegisterHandlers(s.e, s)
+	s.e.POST("/calculate/json", s.CalculateJSON)
+	s.e.POST("/calculate/luck", s.Luck)
+	s.e.POST("/calculate/matchdays", s.Matchdays)
+	s.e.POST("/calculate/trend", s.Trend)
+	s.e.GET("/calculate/json/schema", s.CalendarSchema)
 }
 
 func (s *MyServer) Serve(port string) error {
@@ -43,25 +78,271 @@ func (s *MyServer) Serve(port string) error {
 	return s.e.Start(port)
 }
 
+// Calculate ranks the uploaded calendar. The ranks keep to the
+// fantalegheEV-api unless the client asks for the diagnostics or for
+// provisional ranks, which only the extended ranks can tell apart.
 func (s *MyServer) Calculate(ctx echo.Context) error {
+	opts := calculate.Options{}
+	result, err := s.rankUpload(ctx, &opts)
+	if err != nil {
+		return err
+	}
+	if ctx.FormValue("diagnostics") == "true" {
+		return ctx.JSON(http.StatusOK, calculateResponse{Ranks: result.Ranks, Sheet: result.Sheet, Report: result.Report})
+	}
+	if opts.Incomplete == calculate.IncompleteProvisional {
+		return ctx.JSON(http.StatusOK, result.Ranks)
+	}
+	return ctx.JSON(http.StatusOK, calculate.APIRanks(result.Ranks))
+}
+
+// Luck reports who is lucky and who is not in the uploaded calendar, taking
+// the same form fields as Calculate.
+func (s *MyServer) Luck(ctx echo.Context) error {
+	result, err := s.rankUpload(ctx, &calculate.Options{Luck: true})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result.Luck)
+}
+
+// Matchdays breaks the expected value of the uploaded calendar down round by
+// round, taking the same form fields as Calculate.
+func (s *MyServer) Matchdays(ctx echo.Context) error {
+	result, err := s.rankUpload(ctx, &calculate.Options{Matchdays: true})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result.Matchdays)
+}
+
+// Trend returns how the standings of the uploaded calendar moved after every
+// matchday, as JSON for charting or, with the format form field set to csv,
+// as a CSV download. It takes the same form fields as Calculate.
+func (s *MyServer) Trend(ctx echo.Context) error {
+	format := strings.ToLower(strings.TrimSpace(ctx.FormValue("format")))
+	if format != "" && format != "json" && format != "csv" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid format: expected json or csv")
+	}
+	result, err := s.rankUpload(ctx, &calculate.Options{Trend: true})
+	if err != nil {
+		return err
+	}
+	if format != "csv" {
+		return ctx.JSON(http.StatusOK, result.Trend)
+	}
+
+	var body bytes.Buffer
+	if err := result.Trend.WriteCSV(&body); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to write trend: "+err.Error())
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="trend.csv"`)
+	return ctx.Blob(http.StatusOK, "text/csv; charset=utf-8", body.Bytes())
+}
+
+// rankUpload ranks the calendar uploaded as the file form field with the
+// options of the form, read into opts on top of the reports it asks for.
+// Failures come back as the HTTP error to return.
+func (s *MyServer) rankUpload(ctx echo.Context, opts *calculate.Options) (*calculate.Result, error) {
 	form, err := ctx.MultipartForm()
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse multipart form: "+err.Error())
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Failed to parse multipart form: "+err.Error())
 	}
 
 	files := form.File["file"]
 	if len(files) == 0 {
-		return echo.NewHTTPError(http.StatusBadRequest, "No file uploaded. Please provide an Excel file.")
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "No file uploaded. Please provide an Excel file.")
 	}
 
 	uploadedFileHeader := files[0]
 
+	mode, err := parser.ParseMode(ctx.FormValue("mode"))
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid mode: "+err.Error())
+	}
+
 	fmt.Printf("Uploaded File: %s, Size: %d bytes\n", uploadedFileHeader.Filename, uploadedFileHeader.Size)
 
-	ranks, err := s.calculateService.GetRanks(uploadedFileHeader)
+	opts.Mode, opts.Sheet = mode, ctx.FormValue("sheet")
+	if err := readOptions(ctx, opts); err != nil {
+		return nil, err
+	}
+	result, err := s.calculateService.GetRanks(uploadedFileHeader, *opts)
 	if err != nil {
+		if errors.Is(err, excel.ErrSheetNotFound) {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid sheet: "+err.Error())
+		}
+		var parseErr *parser.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, echo.NewHTTPError(http.StatusUnprocessableEntity, parseErrorResponse{
+				Message: "Calendar contains invalid data: " + err.Error(),
+				Report:  parseErr.Report,
+			})
+		}
 		ctx.Logger().Errorf("Error during calculation for file '%s': %v", uploadedFileHeader.Filename, err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Calculation failed: "+err.Error())
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Calculation failed: "+err.Error())
+	}
+
+	if result.Report != nil {
+		ctx.Response().Header().Set("X-Parse-Warnings", strconv.Itoa(len(result.Report.Warnings)))
+	}
+	return result, nil
+}
+
+// CalculateJSON ranks a calendar sent as a JSON document in the request body.
+// As this endpoint is not part of the fantalegheEV-api, its ranks carry the
+// matches played, byes and pending fixtures of every team.
+func (s *MyServer) CalculateJSON(ctx echo.Context) error {
+	opts := calculate.Options{Mode: parser.ModeStrict}
+	if err := readOptions(ctx, &opts); err != nil {
+		return err
+	}
+
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxCalendarSize)
+	result, err := s.calculateService.GetRanksFromCalendar(body, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Calendar is larger than %d bytes", tooLarge.Limit))
+		}
+		var parseErr *parser.ParseError
+		if errors.As(err, &parseErr) {
+			message := "Calendar does not match the schema: "
+			if errors.Is(err, calculate.ErrInconsistentCalendar) {
+				message = "Calendar is inconsistent: "
+			}
+			return ctx.JSON(http.StatusUnprocessableEntity, parseErrorResponse{
+				Message: message + err.Error(),
+				Report:  parseErr.Report,
+			})
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid calendar: "+err.Error())
 	}
-	return ctx.JSON(http.StatusOK, ranks)
+
+	if result.Report != nil {
+		ctx.Response().Header().Set("X-Parse-Warnings", strconv.Itoa(len(result.Report.Warnings)))
+	}
+	return ctx.JSON(http.StatusOK, result.Ranks)
+}
+
+// readOptions reads the calculation options shared by every ranking request.
+func readOptions(ctx echo.Context, opts *calculate.Options) error {
+	if err := readRules(ctx, opts); err != nil {
+		return err
+	}
+	if err := readAliases(ctx, opts); err != nil {
+		return err
+	}
+	if err := readIncomplete(ctx, opts); err != nil {
+		return err
+	}
+	if err := readMatchdayRange(ctx, opts); err != nil {
+		return err
+	}
+	return readScoring(ctx, opts)
+}
+
+// readMatchdayRange reads the from and to league matchdays the ranking is
+// limited to, e.g. from=20 for the second half of a 38 round season or to=10
+// for the standings as of matchday 10. Either can be left out.
+func readMatchdayRange(ctx echo.Context, opts *calculate.Options) error {
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{{"from", &opts.From}, {"to", &opts.To}} {
+		text := strings.TrimSpace(ctx.FormValue(field.name))
+		if text == "" {
+			continue
+		}
+		matchday, err := strconv.Atoi(text)
+		if err != nil || matchday < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid %s matchday: %q is not a matchday number", field.name, text))
+		}
+		*field.value = matchday
+	}
+	if opts.To > 0 && opts.From > opts.To {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid matchday range: from %d is after to %d", opts.From, opts.To))
+	}
+	return nil
+}
+
+// readRules reads the goal rules a request asks for: rulesMode is "replace"
+// or "check", ev is "neutral" to compare the teams without the home bonus,
+// rules the JSON of rules.Rules, 66 then every 6 points when missing. They
+// come from the form or from the query string.
+func readRules(ctx echo.Context, opts *calculate.Options) error {
+	mode, err := rules.ParseMode(ctx.FormValue("rulesMode"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid rules mode: "+err.Error())
+	}
+	evMode, err := calculate.ParseEVMode(ctx.FormValue("ev"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid expected value mode: "+err.Error())
+	}
+	opts.EVMode = evMode
+	if mode == rules.ModeOff && evMode == calculate.EVGoals {
+		return nil
+	}
+
+	r, err := rules.Parse(ctx.FormValue("rules"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid rules: "+err.Error())
+	}
+	opts.RulesMode, opts.Rules = mode, r
+	return nil
+}
+
+// readIncomplete reads what to do with rounds that still have fixtures to
+// play: "include" them as they stand, "exclude" them or rank them as
+// "provisional".
+func readIncomplete(ctx echo.Context, opts *calculate.Options) error {
+	mode, err := calculate.ParseIncompleteMode(ctx.FormValue("incomplete"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid incomplete rounds mode: "+err.Error())
+	}
+	opts.Incomplete = mode
+	return nil
+}
+
+// readScoring reads the league points of a request, the JSON of
+// scoring.ScoringRules sent in the scoring field or query parameter, e.g.
+// {"win": 2} for 2/1/0. Missing points keep the usual 3/1/0.
+func readScoring(ctx echo.Context, opts *calculate.Options) error {
+	s, err := scoring.Parse(ctx.FormValue("scoring"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid scoring: "+err.Error())
+	}
+	opts.Scoring = s
+	return nil
+}
+
+// readAliases reads the team aliases of a request, a JSON object of alias to
+// canonical name sent as the aliases file or field, or in the query string.
+func readAliases(ctx echo.Context, opts *calculate.Options) error {
+	data := []byte(ctx.FormValue("aliases"))
+	if fileHeader, err := ctx.FormFile("aliases"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to open aliases file: "+err.Error())
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read aliases file: "+err.Error())
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	aliases, err := teams.ParseAliases(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid aliases: "+err.Error())
+	}
+	opts.Aliases = aliases
+	return nil
+}
+
+// CalendarSchema publishes the JSON Schema that CalculateJSON validates against.
+func (s *MyServer) CalendarSchema(ctx echo.Context) error {
+	return ctx.Blob(http.StatusOK, "application/schema+json", parser.CalendarSchema)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -14,19 +15,33 @@ import (
 
 	api "github.com/antpas14/fantalegheEV-api"
 	"github.com/labstack/echo/v4"
+
+	"fantalegheGO/internal/calculate"
+	"fantalegheGO/internal/excel"
+	"fantalegheGO/internal/parser"
+	"fantalegheGO/internal/rules"
+	"fantalegheGO/internal/scoring"
 )
 
 type MockCalculate struct {
-	GetRanksFunc func(fileHeader *multipart.FileHeader) ([]api.Rank, error)
+	GetRanksFunc             func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error)
+	GetRanksFromCalendarFunc func(calendar io.Reader, opts calculate.Options) (*calculate.Result, error)
 }
 
-func (m *MockCalculate) GetRanks(fileHeader *multipart.FileHeader) ([]api.Rank, error) {
+func (m *MockCalculate) GetRanks(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
 	if m.GetRanksFunc != nil {
-		return m.GetRanksFunc(fileHeader)
+		return m.GetRanksFunc(fileHeader, opts)
 	}
 	return nil, errors.New("GetRanks not implemented in MockCalculate")
 }
 
+func (m *MockCalculate) GetRanksFromCalendar(calendar io.Reader, opts calculate.Options) (*calculate.Result, error) {
+	if m.GetRanksFromCalendarFunc != nil {
+		return m.GetRanksFromCalendarFunc(calendar, opts)
+	}
+	return nil, errors.New("GetRanksFromCalendar not implemented in MockCalculate")
+}
+
 type MockFileHeaderOpener struct {
 	OpenFunc func() (io.Reader, error)
 	FileName string
@@ -48,17 +63,19 @@ func TestCalculateEndpoint(t *testing.T) {
 		fileContent        string
 		fileName           string
 		fileMimeType       string
+		formFields         map[string]string
 		expectStatusCode   int
 		expectBodyContains string
+		expectBodyExcludes string
 		expectRanks        []api.Rank
 	}{
 		{
 			name: "Successful calculation",
 			mockCalculate: &MockCalculate{
-				GetRanksFunc: func(fileHeader *multipart.FileHeader) ([]api.Rank, error) {
-					return []api.Rank{
-						{Team: apiString("TeamA"), Points: apiInt(10), EvPoints: apiFloat64(5.5)},
-					}, nil
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					return &calculate.Result{Ranks: []calculate.Rank{
+						{Rank: api.Rank{Team: apiString("TeamA"), Points: apiInt(10), EvPoints: apiFloat64(5.5)}},
+					}}, nil
 				},
 			},
 			fileContent:      "dummy excel data",
@@ -72,7 +89,7 @@ func TestCalculateEndpoint(t *testing.T) {
 		{
 			name: "No file uploaded",
 			mockCalculate: &MockCalculate{
-				GetRanksFunc: func(fileHeader *multipart.FileHeader) ([]api.Rank, error) {
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
 					return nil, nil
 				},
 			},
@@ -84,7 +101,7 @@ func TestCalculateEndpoint(t *testing.T) {
 		{
 			name: "CalculateService returns error",
 			mockCalculate: &MockCalculate{
-				GetRanksFunc: func(fileHeader *multipart.FileHeader) ([]api.Rank, error) {
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
 					return nil, errors.New("internal calculation error")
 				},
 			},
@@ -94,6 +111,248 @@ func TestCalculateEndpoint(t *testing.T) {
 			expectStatusCode:   http.StatusInternalServerError,
 			expectBodyContains: "Calculation failed: internal calculation error",
 		},
+		{
+			name: "Strict mode returns parse diagnostics",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.Mode != parser.ModeStrict {
+						return nil, errors.New("expected strict mode")
+					}
+					report := &parser.ParseReport{Errors: []parser.Diagnostic{
+						{Row: 14, Column: 5, Code: parser.CodeInvalidResult, Value: "2-x", Severity: parser.SeverityError},
+					}}
+					return nil, &parser.ParseError{Report: report}
+				},
+			},
+			fileContent:        "some excel data",
+			fileName:           "strict.xlsx",
+			formFields:         map[string]string{"mode": "strict"},
+			expectStatusCode:   http.StatusUnprocessableEntity,
+			expectBodyContains: `"code":"invalid_result"`,
+		},
+		{
+			name: "Diagnostics requested",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					return &calculate.Result{
+						Ranks: []calculate.Rank{{Rank: api.Rank{Team: apiString("TeamA"), Points: apiInt(10), EvPoints: apiFloat64(5.5)}}},
+						Report: &parser.ParseReport{Warnings: []parser.Diagnostic{
+							{Row: 3, Column: 1, Code: parser.CodeSkippedRow, Value: "Calendario", Severity: parser.SeverityWarning},
+						}},
+					}, nil
+				},
+			},
+			fileContent:        "some excel data",
+			fileName:           "lenient.xlsx",
+			formFields:         map[string]string{"diagnostics": "true"},
+			expectStatusCode:   http.StatusOK,
+			expectBodyContains: `"warnings":[{"row":3,"column":1,"code":"skipped_row"`,
+		},
+		{
+			name: "Sheet form field",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.Sheet != "Calendario" {
+						return nil, errors.New("expected the Calendario sheet")
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{}, Sheet: opts.Sheet, Report: &parser.ParseReport{}}, nil
+				},
+			},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"sheet": "Calendario", "diagnostics": "true"},
+			expectStatusCode:   http.StatusOK,
+			expectBodyContains: `"sheet":"Calendario"`,
+		},
+		{
+			name: "Unknown sheet",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					return nil, fmt.Errorf("failed to read excel file: %w", excel.ErrSheetNotFound)
+				},
+			},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"sheet": "Mercato"},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid sheet",
+		},
+		{
+			name: "Rules form fields",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.RulesMode != rules.ModeCheck || opts.Rules.CloseGameMargin != 4 {
+						return nil, fmt.Errorf("expected check mode with a close game margin, got %+v", opts)
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{}}, nil
+				},
+			},
+			fileContent:      "some excel data",
+			fileName:         "league.xlsx",
+			formFields:       map[string]string{"rulesMode": "check", "rules": `{"thresholds": [66], "step": 6, "closeGameMargin": 4}`},
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name: "Neutral expected value",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.EVMode != calculate.EVNeutral || opts.RulesMode != rules.ModeOff || opts.Rules.HomeBonus != 2 {
+						return nil, fmt.Errorf("expected neutral expected value with a home bonus, got %+v", opts)
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{}}, nil
+				},
+			},
+			fileContent:      "some excel data",
+			fileName:         "league.xlsx",
+			formFields:       map[string]string{"ev": "neutral", "rules": `{"thresholds": [66], "step": 6, "homeBonus": 2}`},
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid expected value mode",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"ev": "home"},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid expected value mode",
+		},
+		{
+			name: "Aliases form field",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.Aliases.Resolve("Pippo United") != "FC Pippo" {
+						return nil, errors.New("expected Pippo United to be an alias of FC Pippo")
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{}}, nil
+				},
+			},
+			fileContent:      "some excel data",
+			fileName:         "league.xlsx",
+			formFields:       map[string]string{"aliases": `{"Pippo United": "FC Pippo"}`},
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid aliases",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"aliases": `{"A": "B", "B": "A"}`},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid aliases",
+		},
+		{
+			name: "Provisional incomplete rounds",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.Incomplete != calculate.IncompleteProvisional {
+						return nil, fmt.Errorf("expected provisional incomplete rounds, got %+v", opts)
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{{Rank: api.Rank{Team: apiString("TeamA")}, Pending: 1, Provisional: true}}}, nil
+				},
+			},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"incomplete": "provisional"},
+			expectStatusCode:   http.StatusOK,
+			expectBodyContains: `"pending":1,"provisional":true`,
+		},
+		{
+			name: "Bare ranks keep to the API",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					return &calculate.Result{Ranks: []calculate.Rank{{Rank: api.Rank{Team: apiString("TeamA")}, Played: 2, Pending: 1}}}, nil
+				},
+			},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			expectStatusCode:   http.StatusOK,
+			expectBodyExcludes: "pending",
+			expectRanks:        []api.Rank{{Team: apiString("TeamA")}},
+		},
+		{
+			name:               "Invalid incomplete rounds mode",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"incomplete": "skip"},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid incomplete rounds mode",
+		},
+		{
+			name: "Scoring form field",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.Scoring != (scoring.ScoringRules{Win: 2, Draw: 1, BonusMargin: 3, Bonus: 1}) {
+						return nil, fmt.Errorf("expected 2/1/0 with a bonus point, got %+v", opts.Scoring)
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{}}, nil
+				},
+			},
+			fileContent:      "some excel data",
+			fileName:         "league.xlsx",
+			formFields:       map[string]string{"scoring": `{"win": 2, "bonusMargin": 3, "bonus": 1}`},
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid scoring",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"scoring": `{"win": 0}`},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid scoring",
+		},
+		{
+			name: "Matchday range form fields",
+			mockCalculate: &MockCalculate{
+				GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+					if opts.From != 20 || opts.To != 38 {
+						return nil, fmt.Errorf("expected matchdays 20 to 38, got %d to %d", opts.From, opts.To)
+					}
+					return &calculate.Result{Ranks: []calculate.Rank{}}, nil
+				},
+			},
+			fileContent:      "some excel data",
+			fileName:         "league.xlsx",
+			formFields:       map[string]string{"from": "20", "to": " 38"},
+			expectStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Invalid matchday",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"to": "ritorno"},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid to matchday",
+		},
+		{
+			name:               "Inverted matchday range",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"from": "20", "to": "19"},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid matchday range",
+		},
+		{
+			name:               "Invalid rules",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "league.xlsx",
+			formFields:         map[string]string{"rulesMode": "replace", "rules": `{"thresholds": []}`},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid rules",
+		},
+		{
+			name:               "Invalid mode",
+			mockCalculate:      &MockCalculate{},
+			fileContent:        "some excel data",
+			fileName:           "test.xlsx",
+			formFields:         map[string]string{"mode": "loose"},
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid mode",
+		},
 	}
 
 	for _, tt := range tests {
@@ -104,23 +363,129 @@ func TestCalculateEndpoint(t *testing.T) {
 			}
 			server.setupRoutes()
 
-			body := &bytes.Buffer{}
-			writer := multipart.NewWriter(body)
+			req := uploadRequest(t, "/calculate", tt.fileName, tt.fileContent, tt.formFields)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
 
-			if tt.fileName != "" {
-				part, err := writer.CreateFormFile("file", tt.fileName)
-				if err != nil {
-					t.Fatalf("Failed to create form file: %v", err)
+			if rec.Code != tt.expectStatusCode {
+				t.Errorf("Expected status %d, got %d. Response: %s", tt.expectStatusCode, rec.Code, rec.Body.String())
+			}
+
+			if tt.expectBodyContains != "" {
+				if !strings.Contains(rec.Body.String(), tt.expectBodyContains) {
+					t.Errorf("Expected body to contain '%s', got '%s'", tt.expectBodyContains, rec.Body.String())
 				}
-				_, err = io.Copy(part, bytes.NewReader([]byte(tt.fileContent)))
+			}
+			if tt.expectBodyExcludes != "" && strings.Contains(rec.Body.String(), tt.expectBodyExcludes) {
+				t.Errorf("Expected body not to contain '%s', got '%s'", tt.expectBodyExcludes, rec.Body.String())
+			}
+
+			if tt.expectStatusCode == http.StatusOK && tt.expectRanks != nil {
+				var gotRanks []api.Rank
+				err := json.Unmarshal(rec.Body.Bytes(), &gotRanks)
 				if err != nil {
-					t.Fatalf("Failed to write file content: %v", err)
+					t.Fatalf("Failed to unmarshal response body: %v", err)
+				}
+				if !reflect.DeepEqual(gotRanks, tt.expectRanks) {
+					t.Errorf("Expected ranks %v, got %v", tt.expectRanks, gotRanks)
 				}
 			}
-			writer.Close()
+		})
+	}
+}
 
-			req := httptest.NewRequest(http.MethodPost, "/calculate", body)
-			req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+func TestCalculateJSONEndpoint(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		name               string
+		mockCalculate      *MockCalculate
+		body               string
+		expectStatusCode   int
+		expectBodyContains string
+		expectRanks        []api.Rank
+	}{
+		{
+			name: "Successful calculation",
+			mockCalculate: &MockCalculate{
+				GetRanksFromCalendarFunc: func(calendar io.Reader, opts calculate.Options) (*calculate.Result, error) {
+					data, _ := io.ReadAll(calendar)
+					if string(data) != `{"matchdays":[]}` {
+						t.Errorf("Expected the request body to reach the service, got %q", data)
+					}
+					return &calculate.Result{
+						Ranks:  []calculate.Rank{{Rank: api.Rank{Team: apiString("TeamA"), Points: apiInt(3), EvPoints: apiFloat64(1.5)}}},
+						Report: &parser.ParseReport{Layout: "json"},
+					}, nil
+				},
+			},
+			body:             `{"matchdays":[]}`,
+			expectStatusCode: http.StatusOK,
+			expectRanks:      []api.Rank{{Team: apiString("TeamA"), Points: apiInt(3), EvPoints: apiFloat64(1.5)}},
+		},
+		{
+			name: "Calendar not matching the schema",
+			mockCalculate: &MockCalculate{
+				GetRanksFromCalendarFunc: func(calendar io.Reader, opts calculate.Options) (*calculate.Result, error) {
+					report := &parser.ParseReport{Layout: "json", Errors: []parser.Diagnostic{
+						{Code: parser.CodeSchemaViolation, Path: "/matchdays/0/fixtures/0/home/goals", Message: "must be at least 0"},
+					}}
+					return nil, fmt.Errorf("failed to get team results: %w", &parser.ParseError{Report: report})
+				},
+			},
+			body:               `{"matchdays":[]}`,
+			expectStatusCode:   http.StatusUnprocessableEntity,
+			expectBodyContains: `"path":"/matchdays/0/fixtures/0/home/goals"`,
+		},
+		{
+			name: "Inconsistent calendar",
+			mockCalculate: &MockCalculate{
+				GetRanksFromCalendarFunc: func(calendar io.Reader, opts calculate.Options) (*calculate.Result, error) {
+					report := &parser.ParseReport{Layout: "json", Errors: []parser.Diagnostic{
+						{Code: parser.CodeDuplicateMatchday, Message: "matchday 1 appears more than once"},
+					}}
+					return nil, fmt.Errorf("%w: %w", calculate.ErrInconsistentCalendar, &parser.ParseError{Report: report})
+				},
+			},
+			body:               `{"matchdays":[]}`,
+			expectStatusCode:   http.StatusUnprocessableEntity,
+			expectBodyContains: "Calendar is inconsistent",
+		},
+		{
+			name: "Calendar too large",
+			mockCalculate: &MockCalculate{
+				GetRanksFromCalendarFunc: func(calendar io.Reader, opts calculate.Options) (*calculate.Result, error) {
+					_, err := io.ReadAll(calendar)
+					return nil, fmt.Errorf("parser: failed to read JSON calendar: %w", err)
+				},
+			},
+			body:               `{"matchdays":[` + strings.Repeat(" ", maxCalendarSize) + `]}`,
+			expectStatusCode:   http.StatusRequestEntityTooLarge,
+			expectBodyContains: "Calendar is larger than",
+		},
+		{
+			name: "Malformed JSON",
+			mockCalculate: &MockCalculate{
+				GetRanksFromCalendarFunc: func(calendar io.Reader, opts calculate.Options) (*calculate.Result, error) {
+					return nil, errors.New("parser: invalid JSON calendar: unexpected EOF")
+				},
+			},
+			body:               `{"matchdays":`,
+			expectStatusCode:   http.StatusBadRequest,
+			expectBodyContains: "Invalid calendar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &MyServer{
+				e:                e,
+				calculateService: tt.mockCalculate,
+			}
+			server.setupRoutes()
+
+			req := httptest.NewRequest(http.MethodPost, "/calculate/json", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 			rec := httptest.NewRecorder()
 			e.ServeHTTP(rec, req)
@@ -135,10 +500,9 @@ func TestCalculateEndpoint(t *testing.T) {
 				}
 			}
 
-			if tt.expectStatusCode == http.StatusOK && tt.expectRanks != nil {
+			if tt.expectRanks != nil {
 				var gotRanks []api.Rank
-				err := json.Unmarshal(rec.Body.Bytes(), &gotRanks)
-				if err != nil {
+				if err := json.Unmarshal(rec.Body.Bytes(), &gotRanks); err != nil {
 					t.Fatalf("Failed to unmarshal response body: %v", err)
 				}
 				if !reflect.DeepEqual(gotRanks, tt.expectRanks) {
@@ -149,8 +513,194 @@ func TestCalculateEndpoint(t *testing.T) {
 	}
 }
 
+func TestCalendarSchemaEndpoint(t *testing.T) {
+	e := echo.New()
+	server := &MyServer{e: e, calculateService: &MockCalculate{}}
+	server.setupRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/calculate/json/schema", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if got := rec.Header().Get(echo.HeaderContentType); got != "application/schema+json" {
+		t.Errorf("Expected content type application/schema+json, got %q", got)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), parser.CalendarSchema) {
+		t.Errorf("Expected the embedded calendar schema, got %s", rec.Body.String())
+	}
+}
+
+func TestLuckEndpoint(t *testing.T) {
+	e := echo.New()
+	server := &MyServer{e: e, calculateService: &MockCalculate{
+		GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+			if opts.Incomplete != calculate.IncompleteExclude || !opts.Luck {
+				return nil, fmt.Errorf("expected the form options and the luck report to reach the service, got %+v", opts)
+			}
+			return &calculate.Result{
+				Ranks: []calculate.Rank{},
+				Luck: &calculate.LuckReport{
+					Teams:           []calculate.Luck{{Team: "TeamA", Points: 4, EvPoints: 2.5, Delta: 1.5, Rank: 1, EvRank: 2}},
+					RankCorrelation: 0.5,
+				},
+			}, nil
+		},
+	}}
+	server.setupRoutes()
+
+	req := uploadRequest(t, "/calculate/luck", "league.xlsx", "some excel data", map[string]string{"incomplete": "exclude"})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Response: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var got calculate.LuckReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	if got.RankCorrelation != 0.5 || len(got.Teams) != 1 || got.Teams[0].Delta != 1.5 || got.Teams[0].EvRank != 2 {
+		t.Errorf("Expected the luck report of the service, got %+v", got)
+	}
+
+	req = uploadRequest(t, "/calculate/luck", "", "", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without a file, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestMatchdaysEndpoint(t *testing.T) {
+	e := echo.New()
+	server := &MyServer{e: e, calculateService: &MockCalculate{
+		GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+			if !opts.Matchdays {
+				return nil, fmt.Errorf("expected the matchdays to be asked for, got %+v", opts)
+			}
+			return &calculate.Result{
+				Ranks: []calculate.Rank{},
+				Matchdays: []calculate.Matchday{
+					{Matchday: 1, Teams: []calculate.MatchdayTeam{{Team: "TeamA", EvPoints: 3, Points: 3, Wins: 9, Percentile: 100}}},
+				},
+			}, nil
+		},
+	}}
+	server.setupRoutes()
+
+	req := uploadRequest(t, "/calculate/matchdays", "league.xlsx", "some excel data", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Response: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	expected := `[{"matchday":1,"teams":[{"team":"TeamA","evPoints":3,"points":3,"wins":9,"draws":0,"losses":0,"percentile":100}]}]`
+	if got := strings.TrimSpace(rec.Body.String()); got != expected {
+		t.Errorf("Expected body %s, got %s", expected, got)
+	}
+}
+
+func TestTrendEndpoint(t *testing.T) {
+	e := echo.New()
+	server := &MyServer{e: e, calculateService: &MockCalculate{
+		GetRanksFunc: func(fileHeader *multipart.FileHeader, opts calculate.Options) (*calculate.Result, error) {
+			if !opts.Trend {
+				return nil, fmt.Errorf("expected the trend to be asked for, got %+v", opts)
+			}
+			return &calculate.Result{
+				Ranks: []calculate.Rank{},
+				Trend: &calculate.Trend{
+					Matchdays: []int{1},
+					Teams:     []calculate.TeamSeries{{Team: "TeamA", Points: []int{3}, EvPoints: []float64{2.5}, Rank: []int{1}, EvRank: []int{1}}},
+				},
+			}, nil
+		},
+	}}
+	server.setupRoutes()
+
+	tests := []struct {
+		name              string
+		formFields        map[string]string
+		expectStatusCode  int
+		expectContentType string
+		expectBody        string
+	}{
+		{
+			name:              "JSON",
+			expectStatusCode:  http.StatusOK,
+			expectContentType: echo.MIMEApplicationJSON,
+			expectBody:        `{"matchdays":[1],"teams":[{"team":"TeamA","points":[3],"evPoints":[2.5],"rank":[1],"evRank":[1]}]}`,
+		},
+		{
+			name:              "CSV",
+			formFields:        map[string]string{"format": "csv"},
+			expectStatusCode:  http.StatusOK,
+			expectContentType: "text/csv; charset=utf-8",
+			expectBody:        "matchday,team,points,evPoints,rank,evRank\n1,TeamA,3,2.5,1,1",
+		},
+		{
+			name:             "Unknown format",
+			formFields:       map[string]string{"format": "xml"},
+			expectStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := uploadRequest(t, "/calculate/trend", "league.xlsx", "some excel data", tt.formFields)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectStatusCode {
+				t.Fatalf("Expected status %d, got %d. Response: %s", tt.expectStatusCode, rec.Code, rec.Body.String())
+			}
+			if tt.expectStatusCode != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(got, tt.expectContentType) {
+				t.Errorf("Expected content type %q, got %q", tt.expectContentType, got)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.expectBody {
+				t.Errorf("Expected body %q, got %q", tt.expectBody, got)
+			}
+		})
+	}
+}
+
 // Helper functions
 
+// uploadRequest builds a multipart request uploading fileContent as the file
+// field, when fileName is set, along with the form fields.
+func uploadRequest(t *testing.T, path, fileName, fileContent string, formFields map[string]string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if fileName != "" {
+		part, err := writer.CreateFormFile("file", fileName)
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		if _, err := io.Copy(part, strings.NewReader(fileContent)); err != nil {
+			t.Fatalf("Failed to write file content: %v", err)
+		}
+	}
+	for name, value := range formFields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatalf("Failed to write form field: %v", err)
+		}
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	return req
+}
+
 func apiString(s string) *string {
 	return &s
 }
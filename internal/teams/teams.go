@@ -0,0 +1,135 @@
+package teams
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+var folder = cases.Fold()
+
+// Clean returns a team name as it should be shown: in Unicode NFC form,
+// without leading or trailing spaces and with runs of spaces, including
+// non-breaking ones, collapsed into one.
+func Clean(name string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(name)), " ")
+}
+
+// Key returns the identity of a team name: two names with the same key are
+// the same team. Keys ignore spacing and case.
+func Key(name string) string {
+	return folder.String(Clean(name))
+}
+
+// Aliases maps the names a team went by, e.g. before a mid-season rename, to
+// its canonical name.
+type Aliases struct {
+	canonical map[string]string
+}
+
+// NewAliases builds the aliases from alias to canonical name pairs. Chained
+// aliases are followed, so "A" to "B" and "B" to "C" fold both A and B into
+// C; aliases going round in a circle are an error. An alias given twice, in
+// different spellings, keeps the target of the first in alphabetical order.
+func NewAliases(names map[string]string) (*Aliases, error) {
+	targets := make(map[string]string, len(names))
+	for _, alias := range sortedKeys(names) {
+		canonical := names[alias]
+		if Clean(alias) == "" || Clean(canonical) == "" {
+			return nil, fmt.Errorf("teams: alias %q to %q has an empty name", alias, canonical)
+		}
+		key := Key(alias)
+		if previous, ok := targets[key]; ok && Key(previous) != Key(canonical) {
+			return nil, fmt.Errorf("teams: alias %q is given to both %q and %q", alias, previous, canonical)
+		}
+		if _, ok := targets[key]; !ok && key != Key(canonical) {
+			targets[key] = Clean(canonical)
+		}
+	}
+
+	a := &Aliases{canonical: make(map[string]string, len(targets))}
+	for key, canonical := range targets {
+		seen := map[string]bool{key: true}
+		for {
+			next, ok := targets[Key(canonical)]
+			if !ok {
+				break
+			}
+			if seen[Key(canonical)] {
+				return nil, fmt.Errorf("teams: aliases of %q go round in a circle", canonical)
+			}
+			seen[Key(canonical)] = true
+			canonical = next
+		}
+		a.canonical[key] = canonical
+	}
+	return a, nil
+}
+
+// ParseAliases reads aliases sent as a JSON object of alias to canonical
+// name, e.g. {"Pippo United": "FC Pippo"}.
+func ParseAliases(data []byte) (*Aliases, error) {
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("teams: invalid aliases: %w", err)
+	}
+	return NewAliases(names)
+}
+
+// Resolve returns the canonical name of a team, or the cleaned name when it
+// is no alias. A nil Aliases has no aliases.
+func (a *Aliases) Resolve(name string) string {
+	if a != nil {
+		if canonical, ok := a.canonical[Key(name)]; ok {
+			return canonical
+		}
+	}
+	return Clean(name)
+}
+
+// Names gives every team of a calendar a single name. A team is named after
+// its alias target or, when it has none, after the first spelling met.
+type Names struct {
+	aliases *Aliases
+	names   map[string]string
+}
+
+// NewNames returns the names of a calendar folded with aliases. Alias targets
+// spelled differently name their team after the alias first in alphabetical
+// order.
+func NewNames(aliases *Aliases) *Names {
+	names := make(map[string]string)
+	if aliases != nil {
+		for _, alias := range sortedKeys(aliases.canonical) {
+			canonical := aliases.canonical[alias]
+			if _, ok := names[Key(canonical)]; !ok {
+				names[Key(canonical)] = canonical
+			}
+		}
+	}
+	return &Names{aliases: aliases, names: names}
+}
+
+// Canonical returns the name the team is ranked under.
+func (n *Names) Canonical(name string) string {
+	name = n.aliases.Resolve(name)
+	key := Key(name)
+	if canonical, ok := n.names[key]; ok {
+		return canonical
+	}
+	n.names[key] = name
+	return name
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package teams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name     string
+		team     string
+		expected string
+	}{
+		{name: "Already clean", team: "FC Pippo", expected: "FC Pippo"},
+		{name: "Trailing space", team: "FC Pippo ", expected: "FC Pippo"},
+		{name: "Inner spaces and tabs", team: " FC \t Pippo", expected: "FC Pippo"},
+		{name: "Non-breaking space", team: "FC\u00a0Pippo", expected: "FC Pippo"},
+		{name: "Decomposed accent", team: "Citte\u0300", expected: "Citt\u00e8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Clean(tt.team))
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, Key("FC Pippo"), Key(" fc  PIPPO"))
+	assert.Equal(t, Key("Citt\u00e0"), Key("CITTA\u0300"))
+	assert.NotEqual(t, Key("FC Pippo"), Key("FC Pluto"))
+}
+
+func TestNewAliases(t *testing.T) {
+	tests := []struct {
+		name        string
+		aliases     map[string]string
+		resolved    map[string]string
+		expectedErr string
+	}{
+		{
+			name:     "Rename",
+			aliases:  map[string]string{"Pippo United": "FC Pippo"},
+			resolved: map[string]string{"pippo  united": "FC Pippo", "FC Pippo": "FC Pippo", "FC Pluto ": "FC Pluto"},
+		},
+		{
+			name:     "Chained renames",
+			aliases:  map[string]string{"A": "B", "B": "C"},
+			resolved: map[string]string{"A": "C", "B": "C", "C": "C"},
+		},
+		{
+			name:     "Alias to itself",
+			aliases:  map[string]string{"fc pippo": "FC Pippo"},
+			resolved: map[string]string{"fc pippo": "fc pippo"},
+		},
+		{name: "Circle", aliases: map[string]string{"A": "B", "b": "A"}, expectedErr: "go round in a circle"},
+		{name: "Empty name", aliases: map[string]string{" ": "A"}, expectedErr: `teams: alias " " to "A" has an empty name`},
+		{name: "Alias given twice", aliases: map[string]string{"A": "B", "a ": "C"}, expectedErr: "is given to both"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aliases, err := NewAliases(tt.aliases)
+
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			for name, expected := range tt.resolved {
+				assert.Equal(t, expected, aliases.Resolve(name), "resolving %q", name)
+			}
+		})
+	}
+}
+
+func TestParseAliases(t *testing.T) {
+	aliases, err := ParseAliases([]byte(`{"Pippo United": "FC Pippo"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "FC Pippo", aliases.Resolve("Pippo United"))
+
+	_, err = ParseAliases([]byte(`["Pippo United", "FC Pippo"]`))
+	assert.ErrorContains(t, err, "teams: invalid aliases")
+}
+
+func TestNames_Canonical(t *testing.T) {
+	aliases, err := NewAliases(map[string]string{"Pippo United": "FC Pippo"})
+	require.NoError(t, err)
+	names := NewNames(aliases)
+
+	assert.Equal(t, "FC Pippo", names.Canonical("Pippo United"))
+	assert.Equal(t, "FC Pippo", names.Canonical("fc pippo "))
+	assert.Equal(t, "Real Madrink", names.Canonical("Real  Madrink"))
+	assert.Equal(t, "Real Madrink", names.Canonical("REAL MADRINK"))
+	assert.Equal(t, "Longobarda", NewNames(nil).Canonical("Longobarda"))
+}
+
+func TestNames_CanonicalSpellings(t *testing.T) {
+	// Both aliases point at the same team, spelled two ways: the name must
+	// not depend on map order.
+	for i := 0; i < 20; i++ {
+		aliases, err := NewAliases(map[string]string{"Pippo United": "FC Pippo", "Pippo Utd": "fc pippo", "Bar": "BAR FC", "bar": "Bar FC"})
+		require.NoError(t, err)
+		names := NewNames(aliases)
+
+		assert.Equal(t, "FC Pippo", names.Canonical("Pippo Utd"))
+		assert.Equal(t, "FC Pippo", names.Canonical("Pippo United"))
+		assert.Equal(t, "BAR FC", names.Canonical("bar"))
+	}
+}